@@ -0,0 +1,392 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Cassette 录制/回放。
+//
+// UseCassette 后，client 及其 Session 的请求在 RoundTripper 层被拦截：录制模式下真实发送并把请求/响应
+// 追加写入 cassette 文件，回放模式下只从文件中查找匹配的交互返回，不访问网络。CookieJar、重试、日志等
+// 均在其上层照常工作，回放的 Set-Cookie 同样写入 CookieJar。
+//
+// 写入文件前，请求 URL、请求体、参与匹配的请求头、响应头与文本响应体经 client 的脱敏规则处理（见 SetRedaction），
+// Set-Cookie 只替换 cookie 值；回放时对请求做同样的脱敏后再比较。CassetteConfig.RawResponseBody 可保存响应体原文。
+
+// CassetteMode cassette 工作模式。
+type CassetteMode int
+
+const (
+	// CassetteAuto 文件不存在时录制，存在时回放。
+	CassetteAuto CassetteMode = iota
+	// CassetteRecord 总是访问网络并重新录制，覆盖已有文件。
+	CassetteRecord
+	// CassetteReplay 只回放，文件不存在时 NewCassette 返回错误。
+	CassetteReplay
+)
+
+// ErrCassetteNoMatch 回放时 cassette 中没有与请求匹配的交互。
+var ErrCassetteNoMatch = errors.New("cassette: no matching interaction")
+
+// CassetteRequest cassette 中记录的请求。
+type CassetteRequest struct {
+	Method       string      `json:"method"`
+	URL          string      `json:"url"`
+	Header       http.Header `json:"header,omitempty"` // 仅 CassetteConfig.MatchHeaders 中的请求头
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty"` // 二进制 body 为 "base64"
+}
+
+// CassetteResponse cassette 中记录的响应，body 已解压。
+type CassetteResponse struct {
+	Status       int         `json:"status"`
+	Proto        string      `json:"proto"`
+	Header       http.Header `json:"header"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty"`
+}
+
+// CassetteInteraction 一次请求及其响应。
+type CassetteInteraction struct {
+	Request  CassetteRequest  `json:"request"`
+	Response CassetteResponse `json:"response"`
+}
+
+// cassetteFile cassette 文件格式。
+type cassetteFile struct {
+	Version      int                    `json:"version"`
+	Interactions []*CassetteInteraction `json:"interactions"`
+}
+
+// Cassette 一个 cassette 文件，可被多个 client 共享，并发安全。
+type Cassette struct {
+	path      string
+	cfg       CassetteConfig
+	recording bool
+
+	mu           sync.Mutex
+	interactions []*CassetteInteraction
+	used         []bool
+}
+
+// NewCassette 打开 path 处的 cassette，cfg 为 nil 时使用 CassetteAuto 且只匹配方法与 URL。
+func NewCassette(path string, cfg *CassetteConfig) (*Cassette, error) {
+	c := &Cassette{path: path}
+	if cfg != nil {
+		c.cfg = *cfg
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case c.cfg.Mode == CassetteRecord:
+		c.recording = true
+	case errors.Is(err, os.ErrNotExist) && c.cfg.Mode == CassetteAuto:
+		c.recording = true
+	case err != nil:
+		return nil, fmt.Errorf("cassette: %w", err)
+	default:
+		var f cassetteFile
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("cassette: invalid file %s: %w", path, err)
+		}
+		c.interactions = f.Interactions
+		c.used = make([]bool, len(f.Interactions))
+	}
+	return c, nil
+}
+
+// Recording 是否处于录制模式。
+func (c *Cassette) Recording() bool {
+	return c.recording
+}
+
+// Interactions 返回已加载或已录制的交互。
+func (c *Cassette) Interactions() []*CassetteInteraction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*CassetteInteraction(nil), c.interactions...)
+}
+
+// UseCassette 让 client（含 Session）的请求经 cassette 录制/回放，nil 恢复真实网络。
+func (h *HttpClient) UseCassette(c *Cassette) {
+	if c == nil {
+		h.setRoundTripperWrap(nil)
+		return
+	}
+	h.setRoundTripperWrap(func(next http.RoundTripper) http.RoundTripper {
+		return &cassetteTransport{c: c, h: h, next: next}
+	})
+}
+
+// cassetteTransport 绑定到某个底层传输的 cassette，脱敏规则取自 h。
+type cassetteTransport struct {
+	c    *Cassette
+	h    *HttpClient
+	next http.RoundTripper
+}
+
+func (t *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	rd := t.h.getRedactor()
+	if t.c.recording {
+		return t.c.record(t.next, rd, req, body)
+	}
+	return t.c.replay(rd, req, body)
+}
+
+// record 真实发送请求并把脱敏后的交互追加到 cassette 文件，返回给上层的仍是原始响应。
+func (c *Cassette) record(next http.RoundTripper, rd *redactor, req *http.Request, body []byte) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	res, err := next.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	resBody, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	header := res.Header.Clone()
+	if strings.EqualFold(header.Get("Content-Encoding"), "gzip") {
+		// 以明文保存，便于阅读与编辑
+		if zr, err := gzip.NewReader(bytes.NewReader(resBody)); err == nil {
+			if plain, err := io.ReadAll(zr); err == nil {
+				resBody = plain
+				header.Del("Content-Encoding")
+				header.Del("Content-Length")
+			}
+		}
+	}
+
+	live := CassetteResponse{Status: res.StatusCode, Proto: res.Proto, Header: header}
+	in := &CassetteInteraction{
+		Request:  CassetteRequest{Method: req.Method, URL: rd.url(req.URL.String())},
+		Response: live,
+	}
+	in.Response.Header = redactResponseHeader(rd, header)
+	in.Request.Body, in.Request.BodyEncoding = encodeCassetteBody(redactCassetteBody(rd, body))
+	saved := resBody
+	if !c.cfg.RawResponseBody {
+		saved = redactCassetteBody(rd, resBody)
+	}
+	in.Response.Body, in.Response.BodyEncoding = encodeCassetteBody(saved)
+	in.Request.Header = c.matchHeaders(rd, req.Header)
+
+	c.mu.Lock()
+	c.interactions = append(c.interactions, in)
+	c.used = append(c.used, true)
+	err = c.saveLocked()
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return live.toHTTP(req, resBody), nil
+}
+
+// replay 返回第一个未使用的匹配交互；匹配的交互均已使用时重复返回最后一个。
+func (c *Cassette) replay(rd *redactor, req *http.Request, body []byte) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	last := -1
+	for i, in := range c.interactions {
+		if !c.match(rd, req, body, &in.Request) {
+			continue
+		}
+		if !c.used[i] {
+			last = i
+			break
+		}
+		last = i
+	}
+	if last < 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrCassetteNoMatch, req.Method, req.URL)
+	}
+	c.used[last] = true
+	resp := &c.interactions[last].Response
+	resBody, err := decodeCassetteBody(resp.Body, resp.BodyEncoding)
+	if err != nil {
+		return nil, err
+	}
+	return resp.toHTTP(req, resBody), nil
+}
+
+// match 判断请求是否与记录的请求匹配；请求先经与录制时相同的脱敏再比较。
+func (c *Cassette) match(rd *redactor, req *http.Request, body []byte, rec *CassetteRequest) bool {
+	if req.Method != rec.Method {
+		return false
+	}
+	u, err := url.Parse(rec.URL)
+	if err != nil {
+		return false
+	}
+	live, err := url.Parse(rd.url(req.URL.String()))
+	if err != nil || !c.sameURL(live, u) {
+		return false
+	}
+	if c.cfg.Match != nil {
+		return c.cfg.Match(req, body, rec)
+	}
+	if c.cfg.MatchBody {
+		recBody, err := decodeCassetteBody(rec.Body, rec.BodyEncoding)
+		if err != nil || !bytes.Equal(redactCassetteBody(rd, body), recBody) {
+			return false
+		}
+	}
+	header := c.matchHeaders(rd, req.Header)
+	for _, name := range c.cfg.MatchHeaders {
+		if !slices.Equal(header.Values(name), rec.Header.Values(name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchHeaders 取出参与匹配的请求头并脱敏，没有时返回 nil。
+func (c *Cassette) matchHeaders(rd *redactor, header http.Header) http.Header {
+	var out http.Header
+	for _, name := range c.cfg.MatchHeaders {
+		if vs := header.Values(name); len(vs) > 0 {
+			if out == nil {
+				out = make(http.Header)
+			}
+			out[http.CanonicalHeaderKey(name)] = vs
+		}
+	}
+	return rd.header(out)
+}
+
+// redactCassetteBody 脱敏文本 body 中的敏感字段，二进制 body 原样返回。
+func redactCassetteBody(rd *redactor, b []byte) []byte {
+	if len(b) == 0 || !utf8.Valid(b) || bytes.IndexByte(b, 0) >= 0 {
+		return b
+	}
+	return []byte(rd.body(string(b)))
+}
+
+// redactResponseHeader 脱敏响应头；Set-Cookie 只替换 cookie 值并保留名称与属性，回放时仍能写入 CookieJar。
+func redactResponseHeader(rd *redactor, header http.Header) http.Header {
+	out := rd.header(header)
+	if !rd.headers["Set-Cookie"] {
+		return out
+	}
+	lines := header.Values("Set-Cookie")
+	if len(lines) == 0 {
+		return out
+	}
+	masked := make([]string, len(lines))
+	for i, line := range lines {
+		masked[i] = rd.replacement
+		if name, rest, ok := strings.Cut(line, "="); ok {
+			_, attrs, _ := strings.Cut(rest, ";")
+			masked[i] = name + "=" + rd.replacement
+			if attrs != "" {
+				masked[i] += ";" + attrs
+			}
+		}
+	}
+	out["Set-Cookie"] = masked
+	return out
+}
+
+// sameURL 比较 URL，查询参数不区分顺序并忽略 IgnoreQuery 中的参数。
+func (c *Cassette) sameURL(a, b *url.URL) bool {
+	if a.Scheme != b.Scheme || a.Host != b.Host || a.EscapedPath() != b.EscapedPath() {
+		return false
+	}
+	qa, qb := a.Query(), b.Query()
+	for _, name := range c.cfg.IgnoreQuery {
+		qa.Del(name)
+		qb.Del(name)
+	}
+	return qa.Encode() == qb.Encode()
+}
+
+// saveLocked 原子写入 cassette 文件，调用方须持有 c.mu。
+func (c *Cassette) saveLocked() error {
+	data, err := json.MarshalIndent(cassetteFile{Version: 1, Interactions: c.interactions}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(c.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("cassette: %w", err)
+		}
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("cassette: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("cassette: %w", err)
+	}
+	return nil
+}
+
+// toHTTP 构建回放给上层的响应。
+func (r *CassetteResponse) toHTTP(req *http.Request, body []byte) *http.Response {
+	proto := r.Proto
+	if proto == "" {
+		proto = "HTTP/1.1"
+	}
+	major, minor, ok := http.ParseHTTPVersion(proto)
+	if !ok {
+		major, minor = 1, 1
+	}
+	header := r.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(r.Status) + " " + http.StatusText(r.Status),
+		StatusCode:    r.Status,
+		Proto:         proto,
+		ProtoMajor:    major,
+		ProtoMinor:    minor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// encodeCassetteBody 文本 body 原样保存，二进制 body 使用 base64。
+func encodeCassetteBody(b []byte) (string, string) {
+	if utf8.Valid(b) && bytes.IndexByte(b, 0) < 0 {
+		return string(b), ""
+	}
+	return base64.StdEncoding.EncodeToString(b), "base64"
+}
+
+func decodeCassetteBody(s, encoding string) ([]byte, error) {
+	switch encoding {
+	case "":
+		return []byte(s), nil
+	case "base64":
+		return base64.StdEncoding.DecodeString(s)
+	default:
+		return nil, fmt.Errorf("cassette: unknown body encoding %q", encoding)
+	}
+}
@@ -0,0 +1,251 @@
+package client
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func newCassetteServer(hits *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		switch r.URL.Path {
+		case "/login":
+			r.ParseForm()
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: "tok-" + r.Form.Get("user"), Path: "/"})
+			w.Write([]byte("ok"))
+		case "/me":
+			c, err := r.Cookie("sid")
+			if err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Encoding", "gzip")
+			zw := gzip.NewWriter(w)
+			io.WriteString(zw, "hello "+c.Value)
+			zw.Close()
+		default:
+			b, _ := io.ReadAll(r.Body)
+			w.Write([]byte(r.Method + " " + r.URL.RawQuery + " " + string(b) + " " + r.Header.Get("X-Tenant")))
+		}
+	}))
+}
+
+func TestCassette_RecordThenReplayWithSession(t *testing.T) {
+	var hits int32
+	ts := newCassetteServer(&hits)
+	path := filepath.Join(t.TempDir(), "fixtures", "login.json")
+
+	run := func() (string, string) {
+		cas, err := NewCassette(path, nil)
+		if err != nil {
+			t.Fatalf("NewCassette failed: %v", err)
+		}
+		c := NewHttpClient(ts.URL)
+		c.UseCassette(cas)
+		s := NewSession()
+		if _, err := c.DoPostWithSession(s, "/login", map[string]string{"user": "tom"}); err != nil {
+			t.Fatalf("login failed: %v", err)
+		}
+		me, err := c.DoGetWithSession(s, "/me")
+		if err != nil {
+			t.Fatalf("me failed: %v", err)
+		}
+		return string(me), s.GetCookieValue(ts.URL, "sid")
+	}
+
+	me, sid := run()
+	if me != "hello tok-tom" || sid != "tok-tom" || hits != 2 {
+		t.Fatalf("record: me=%q sid=%q hits=%d", me, sid, hits)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"body": "hello tok-tom"`) || strings.Contains(string(data), "User-Agent") {
+		t.Fatalf("cassette should store plain bodies and no request headers:\n%s", data)
+	}
+	if strings.Contains(string(data), "sid=tok-tom") || !strings.Contains(string(data), "sid="+DefaultRedactReplacement+"; Path=/") {
+		t.Fatalf("Set-Cookie value should be redacted:\n%s", data)
+	}
+
+	// 回放不访问网络，cookie（值为占位符）仍经 CookieJar 流转
+	ts.Close()
+	me, sid = run()
+	if me != "hello tok-tom" || sid != DefaultRedactReplacement || hits != 2 {
+		t.Fatalf("replay: me=%q sid=%q hits=%d", me, sid, hits)
+	}
+}
+
+func TestCassette_Matching(t *testing.T) {
+	var hits int32
+	ts := newCassetteServer(&hits)
+	defer ts.Close()
+	path := filepath.Join(t.TempDir(), "match.json")
+	cfg := &CassetteConfig{MatchBody: true, MatchHeaders: []string{"X-Tenant"}, IgnoreQuery: []string{"ts"}}
+
+	cas, _ := NewCassette(path, cfg)
+	if !cas.Recording() {
+		t.Fatal("missing file should record in auto mode")
+	}
+	c := NewHttpClient(ts.URL)
+	c.UseCassette(cas)
+	send := func(query, body, tenant string) (string, error) {
+		resp, err := c.Do(&Request{
+			Method: http.MethodPost,
+			Path:   "/echo?" + query,
+			Body:   []byte(body),
+			Header: http.Header{"X-Tenant": {tenant}},
+		})
+		if err != nil {
+			return "", err
+		}
+		return string(resp.Body), nil
+	}
+	send("a=1&b=2&ts=100", "x", "t1")
+	send("a=1&b=2&ts=100", "y", "t1")
+	send("a=1&b=2&ts=100", "x", "t2")
+	if n := len(cas.Interactions()); n != 3 {
+		t.Fatalf("expected 3 recorded interactions, got %d", n)
+	}
+
+	cas, err := NewCassette(path, &CassetteConfig{Mode: CassetteReplay, MatchBody: true, MatchHeaders: []string{"X-Tenant"}, IgnoreQuery: []string{"ts"}})
+	if err != nil || cas.Recording() {
+		t.Fatalf("replay cassette: %v", err)
+	}
+	c.UseCassette(cas)
+	before := atomic.LoadInt32(&hits)
+	for _, tc := range []struct{ query, body, tenant, want string }{
+		{"b=2&a=1&ts=999", "y", "t1", "POST a=1&b=2&ts=100 y t1"}, // 参数顺序与忽略的参数不影响匹配
+		{"a=1&b=2", "x", "t2", "POST a=1&b=2&ts=100 x t2"},
+		{"a=1&b=2", "x", "t2", "POST a=1&b=2&ts=100 x t2"}, // 已用完时重复最后一个匹配
+	} {
+		got, err := send(tc.query, tc.body, tc.tenant)
+		if err != nil || got != tc.want {
+			t.Errorf("%+v: got %q, %v", tc, got, err)
+		}
+	}
+	for _, tc := range []struct{ query, body, tenant string }{
+		{"a=1&b=3", "x", "t1"},
+		{"a=1&b=2", "z", "t1"},
+		{"a=1&b=2", "x", "t3"},
+	} {
+		if _, err := send(tc.query, tc.body, tc.tenant); !errors.Is(err, ErrCassetteNoMatch) {
+			t.Errorf("%+v: expected ErrCassetteNoMatch, got %v", tc, err)
+		}
+	}
+	if atomic.LoadInt32(&hits) != before {
+		t.Fatal("replay should not hit the network")
+	}
+
+	// 自定义匹配替代 body/header 规则
+	cas, _ = NewCassette(path, &CassetteConfig{Match: func(req *http.Request, body []byte, rec *CassetteRequest) bool {
+		return strings.Contains(rec.Body, "y")
+	}})
+	c.UseCassette(cas)
+	if got, _ := send("a=1&b=2&ts=100", "anything", "none"); got != "POST a=1&b=2&ts=100 y t1" {
+		t.Fatalf("custom matcher not used: %q", got)
+	}
+
+	c.UseCassette(nil)
+	if got, _ := send("a=1", "live", "t9"); got != "POST a=1 live t9" {
+		t.Fatalf("UseCassette(nil) should restore the network: %q", got)
+	}
+}
+
+func TestCassette_RedactsSecrets(t *testing.T) {
+	var hits int32
+	ts := newCassetteServer(&hits)
+	defer ts.Close()
+	path := filepath.Join(t.TempDir(), "secret.json")
+	cfg := &CassetteConfig{MatchBody: true, MatchHeaders: []string{"Authorization"}}
+	send := func(c *HttpClient) (string, error) {
+		resp, err := c.Do(&Request{
+			Method: http.MethodPost,
+			Path:   "/echo?access_token=t0k&page=1",
+			Body:   []byte(`{"user":"tom","password":"hunter2"}`),
+			Header: http.Header{"Authorization": {"Bearer s3cret"}, "Content-Type": {"application/json"}},
+		})
+		if err != nil {
+			return "", err
+		}
+		return string(resp.Body), nil
+	}
+
+	cas, _ := NewCassette(path, cfg)
+	c := NewHttpClient(ts.URL)
+	c.UseCassette(cas)
+	got, err := send(c)
+	if err != nil || !strings.Contains(got, "hunter2") {
+		t.Fatalf("recording should return the live response: %q, %v", got, err)
+	}
+	// 响应体回显了请求，同样不应含有敏感值
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), "s3cret") || strings.Contains(string(data), "hunter2") {
+		t.Fatalf("secrets leaked into the cassette:\n%s", data)
+	}
+	in := cas.Interactions()[0]
+	if strings.Contains(in.Request.URL, "t0k") || strings.Contains(in.Request.Body, "hunter2") ||
+		in.Request.Header.Get("Authorization") != DefaultRedactReplacement {
+		t.Fatalf("request not redacted: %+v", in.Request)
+	}
+
+	// 回放时请求经同样的脱敏后匹配，返回脱敏后的响应体
+	cas, _ = NewCassette(path, &CassetteConfig{Mode: CassetteReplay, MatchBody: cfg.MatchBody, MatchHeaders: cfg.MatchHeaders})
+	c.UseCassette(cas)
+	before := atomic.LoadInt32(&hits)
+	if got, err := send(c); err != nil || strings.Contains(got, "hunter2") || atomic.LoadInt32(&hits) != before {
+		t.Fatalf("redacted interaction should still match: %q, %v", got, err)
+	}
+
+	// RawResponseBody 只保留响应体原文，请求仍脱敏
+	rawBody := filepath.Join(t.TempDir(), "raw-body.json")
+	cas, _ = NewCassette(rawBody, &CassetteConfig{MatchBody: true, RawResponseBody: true})
+	c.UseCassette(cas)
+	send(c)
+	if in := cas.Interactions()[0]; !strings.Contains(in.Response.Body, "hunter2") || strings.Contains(in.Request.Body, "hunter2") {
+		t.Fatalf("RawResponseBody should keep only the response body: %+v", in)
+	}
+
+	// 关闭脱敏后保存原文
+	raw := filepath.Join(t.TempDir(), "raw.json")
+	cas, _ = NewCassette(raw, cfg)
+	c.SetRedaction(&RedactConfig{})
+	c.UseCassette(cas)
+	send(c)
+	if in := cas.Interactions()[0]; !strings.Contains(in.Request.URL, "t0k") || !strings.Contains(in.Request.Body, "hunter2") {
+		t.Fatalf("SetRedaction(&RedactConfig{}) should keep the original request: %+v", in.Request)
+	}
+}
+
+func TestCassette_Modes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "none.json")
+	if _, err := NewCassette(path, &CassetteConfig{Mode: CassetteReplay}); err == nil {
+		t.Fatal("replay mode should fail without a cassette file")
+	}
+
+	os.WriteFile(path, []byte("not json"), 0o644)
+	if _, err := NewCassette(path, nil); err == nil {
+		t.Fatal("invalid cassette should fail to load")
+	}
+
+	var hits int32
+	ts := newCassetteServer(&hits)
+	defer ts.Close()
+	cas, err := NewCassette(path, &CassetteConfig{Mode: CassetteRecord})
+	if err != nil || !cas.Recording() {
+		t.Fatalf("record mode should ignore the existing file: %v", err)
+	}
+	c := NewHttpClient(ts.URL)
+	c.UseCassette(cas)
+	if _, err := c.DoHead("/h"); err != nil {
+		t.Fatalf("DoHead failed: %v", err)
+	}
+	cas, err = NewCassette(path, nil)
+	if err != nil || len(cas.Interactions()) != 1 || cas.Interactions()[0].Request.Method != http.MethodHead {
+		t.Fatalf("recorded file not reloaded: %v", err)
+	}
+}
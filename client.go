@@ -1,6 +1,7 @@
 package client
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"net/http/cookiejar"
@@ -8,19 +9,56 @@ import (
 	"sync"
 	"time"
 
-	"go.uber.org/zap"
+	utls "github.com/refraction-networking/utls"
 )
 
+// defaultTLSSessionCacheSize uTLS 会话恢复缓存默认容量。
+const defaultTLSSessionCacheSize = 1024
+
 // HttpClient 封装了 http.Client，提供连接池、代理、JA3 指纹、并发限制等功能。
 type HttpClient struct {
 	client    *http.Client
 	transport *http.Transport
 	jar       http.CookieJar
-	logger    *zap.SugaredLogger
+	logger    Logger // nil 表示不输出日志
 	domain    string
-	headers   map[string]string
-	mu        sync.RWMutex // 保护 headers 和 domain
+	headers   http.Header
+	mu        sync.RWMutex  // 保护 headers、domain 及下列拨号/请求头布局状态
 	semaphore chan struct{} // 并发限速，nil 表示不限
+
+	proxyDial  dialFunc     // SOCKS5 代理拨号函数，nil 表示直连
+	httpProxy  bool         // 是否启用了 HTTP 代理
+	proxyCfg   *ProxyConfig // SetProxy 的配置，nil 表示未设置代理
+	ja3Profile string       // 当前 JA3 profile，空表示使用标准 TLS
+	ja3Pool    []JA3Profile // 轮换候选 profile
+	ja3Rotate  string       // 轮换模式，见 JA3RotateConnection / JA3RotateSession
+	dialGen    uint64       // JA3/出口地址/代理/TLS/Unix socket 配置版本，变化时 Session 重建独占连接池
+	tlsConfig  *tls.Config  // SetTLSConfig 生成的配置，nil 表示默认
+
+	tlsSessionCache utls.ClientSessionCache // uTLS 会话恢复缓存，nil 表示关闭
+	dns             *dnsResolver            // 自定义 DNS 解析，nil 表示使用系统解析
+	localAddrs      *localAddrPool          // 出口源地址池，nil 表示由系统选择
+	sockOpts        socketOptions           // 拨号超时与 socket 选项
+	unixSocket      string                  // TransportConfig.UnixSocket，非空时所有连接拨向该 socket
+	h3              *http3State             // HTTP/3 传输，nil 表示未开启
+	metrics         Metrics                 // 指标采集器，nil 表示关闭
+	tracer          Tracer                  // 分布式追踪，nil 表示只透传调用方的 traceparent
+	redact          *redactor               // 日志脱敏规则，默认 DefaultRedactConfig
+	logPolicy       *LogPolicy              // 请求日志策略，nil 表示 DefaultLogPolicy
+	requestIDHeader string                  // 关联 ID 请求头，空表示不发送
+	requestIDGen    func() string           // 关联 ID 生成函数，nil 表示 UUIDv4
+
+	logConnInfo bool // 请求日志是否输出连接信息
+	logTiming   bool // 请求日志是否输出各阶段耗时
+	logCurl     bool // 请求日志是否输出等价的 curl 命令
+
+	har    *HARRecorder                              // HAR 记录器，nil 表示不记录
+	rtBase http.RoundTripper                         // SetRoundTripper 设置的底层传输，nil 表示使用 transport
+	rtWrap func(http.RoundTripper) http.RoundTripper // 最外层 RoundTripper 包装（cassette 等），nil 表示不包装
+
+	headerOrder  []string          // 请求头写出顺序
+	headerCase   map[string]string // Canonical key -> 调用方传入的原始写法
+	preserveCase bool              // 是否按原始大小写写出请求头
 }
 
 // NewHttpClient 使用默认传输配置创建 HttpClient。
@@ -29,12 +67,29 @@ func NewHttpClient(domain string, timeout ...time.Duration) *HttpClient {
 }
 
 // NewHttpClientWithTransport 使用自定义传输配置创建 HttpClient。
+// 无效的 DNS 选项（PreferIP 取值、Resolve 中的 IP）会被忽略，需要校验时使用 NewHttpClientWithTransportE。
 func NewHttpClientWithTransport(domain string, tc *TransportConfig, timeout ...time.Duration) *HttpClient {
+	h, _ := NewHttpClientWithTransportE(domain, tc, timeout...)
+	return h
+}
+
+// NewHttpClientWithTransportE 同 NewHttpClientWithTransport，但配置无效时返回错误；
+// 返回的 client 始终可用，无效的选项已被忽略。
+func NewHttpClientWithTransportE(domain string, tc *TransportConfig, timeout ...time.Duration) (*HttpClient, error) {
 	defaultTimeout := 30 * time.Second
 	if len(timeout) > 0 && timeout[0] > 0 {
 		defaultTimeout = timeout[0]
 	}
 
+	sockOpts := newSocketOptions(tc)
+	var responseHeaderTimeout, expectContinueTimeout time.Duration
+	var unixSocket string
+	if tc != nil {
+		responseHeaderTimeout = tc.ResponseHeaderTimeout
+		expectContinueTimeout = tc.ExpectContinueTimeout
+		unixSocket = tc.UnixSocket
+	}
+
 	maxIdleConns := 10000
 	maxIdleConnsPerHost := 10000
 	maxConnsPerHost := 10000
@@ -61,6 +116,14 @@ func NewHttpClientWithTransport(domain string, tc *TransportConfig, timeout ...t
 		MaxConnsPerHost:     maxConnsPerHost,
 		DisableKeepAlives:   false,
 		IdleConnTimeout:     idleConnTimeout,
+		// 分阶段超时，未配置时为 0（不单独限制）
+		TLSHandshakeTimeout:   sockOpts.tlsHandshakeTimeout,
+		ResponseHeaderTimeout: responseHeaderTimeout,
+		ExpectContinueTimeout: expectContinueTimeout,
+		// 记录 HTTP 代理 CONNECT 隧道耗时
+		OnProxyConnectResponse: onProxyConnectResponse,
+		// 自定义了 DialContext/TLSClientConfig，需显式开启 HTTP/2 协商
+		ForceAttemptHTTP2: true,
 	}
 	jar, err := cookiejar.New(nil)
 	if err != nil {
@@ -72,7 +135,21 @@ func NewHttpClientWithTransport(domain string, tc *TransportConfig, timeout ...t
 		semaphore = make(chan struct{}, tc.MaxConcurrency)
 	}
 
-	return &HttpClient{
+	dns, dnsErr := newDNSResolver(tc)
+	if dnsErr != nil {
+		dnsErr = fmt.Errorf("invalid DNS config: %w", dnsErr)
+	}
+
+	var sessionCache utls.ClientSessionCache
+	sessionCacheSize := defaultTLSSessionCacheSize
+	if tc != nil && tc.TLSSessionCacheSize != 0 {
+		sessionCacheSize = tc.TLSSessionCacheSize
+	}
+	if sessionCacheSize > 0 {
+		sessionCache = utls.NewLRUClientSessionCache(sessionCacheSize)
+	}
+
+	h := &HttpClient{
 		client: &http.Client{
 			Transport: transport,
 			Timeout:   defaultTimeout,
@@ -80,20 +157,38 @@ func NewHttpClientWithTransport(domain string, tc *TransportConfig, timeout ...t
 		},
 		transport: transport,
 		domain:    domain,
-		headers: map[string]string{
-			"Content-Type": "application/x-www-form-urlencoded",
-			"User-Agent":   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/58.0.3029.110 Safari/537.3",
+		headers: http.Header{
+			"Content-Type": {"application/x-www-form-urlencoded"},
+			"User-Agent":   {"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/58.0.3029.110 Safari/537.3"},
 		},
-		jar:       jar,
-		semaphore: semaphore,
+		jar:        jar,
+		semaphore:  semaphore,
+		headerCase: make(map[string]string),
+
+		tlsSessionCache: sessionCache,
+		dns:             dns,
+		sockOpts:        sockOpts,
+		unixSocket:      unixSocket,
+		redact:          defaultRedactor(),
 	}
+	transport.DialContext = h.dialContext
+	h.client.CheckRedirect = h.checkRedirect
+	return h, dnsErr
 }
 
-// SetDomain 设置默认域名。
+// SetDomain 设置默认域名；"unix:///path/to.sock" 表示经 Unix domain socket 访问。
 func (h *HttpClient) SetDomain(domain string) {
 	h.mu.Lock()
-	defer h.mu.Unlock()
+	// 不同 socket 共用连接池键 localhost:80，切换时需丢弃旧 socket 的连接
+	changed := domainSocket(h.domain) != domainSocket(domain)
 	h.domain = domain
+	if changed {
+		h.dialGen++
+	}
+	h.mu.Unlock()
+	if changed {
+		h.transport.CloseIdleConnections()
+	}
 }
 
 // GetDomain 返回当前默认域名。
@@ -103,30 +198,17 @@ func (h *HttpClient) GetDomain() string {
 	return h.domain
 }
 
-// SetLogger 设置日志记录器。
-func (h *HttpClient) SetLogger(logger *zap.SugaredLogger) {
-	h.logger = logger
-}
-
-// LogInfo 输出 Info 级别日志。
-func (h *HttpClient) LogInfo(msg string, fields ...interface{}) {
-	if h.logger != nil {
-		h.logger.Infow(msg, fields...)
-	}
-}
-
-// LogError 输出 Error 级别日志。
-func (h *HttpClient) LogError(msg string, err error) {
-	if h.logger != nil {
-		h.logger.Errorw(msg, "error", err)
-	}
-}
-
 // Close 关闭所有空闲连接。
 func (h *HttpClient) Close() {
 	if h.transport != nil {
 		h.transport.CloseIdleConnections()
 	}
+	h.mu.RLock()
+	st := h.h3
+	h.mu.RUnlock()
+	if st != nil {
+		st.transport.CloseIdleConnections()
+	}
 }
 
 // buildFullURL 将相对路径拼接为完整 URL；若已是绝对 URL 则直接返回。
@@ -137,6 +219,9 @@ func (h *HttpClient) buildFullURL(path string) string {
 	h.mu.RLock()
 	domain := strings.TrimRight(h.domain, "/")
 	h.mu.RUnlock()
+	if strings.HasPrefix(domain, unixScheme) {
+		domain = unixBaseURL
+	}
 	path = strings.TrimLeft(path, "/")
 	return fmt.Sprintf("%s/%s", domain, path)
 }
@@ -0,0 +1,127 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http/httptrace"
+
+	utls "github.com/refraction-networking/utls"
+)
+
+// ConnInfo 描述一次请求实际使用的连接，标准 TLS 与 JA3（uTLS）连接均会填充 TLS 字段。
+type ConnInfo struct {
+	Proto      string // 响应协议，如 HTTP/1.1、HTTP/2.0
+	RemoteAddr string // 对端地址（经 SOCKS5/HTTP 代理时为代理地址）
+	LocalAddr  string
+	Reused     bool // 是否复用了连接池中的连接
+	WasIdle    bool
+
+	TLS              bool // 是否为 TLS 连接
+	TLSVersion       uint16
+	CipherSuite      uint16
+	ALPN             string
+	ServerName       string
+	DidResume        bool                // 是否为会话恢复握手
+	PeerCertificates []*x509.Certificate // 服务端证书链，leaf 在前
+	JA3              bool                // 是否由 uTLS 完成握手
+}
+
+// TLSVersionName 返回可读的 TLS 版本名，如 "TLS 1.3"。
+func (ci *ConnInfo) TLSVersionName() string {
+	if !ci.TLS {
+		return ""
+	}
+	return tls.VersionName(ci.TLSVersion)
+}
+
+// CipherSuiteName 返回可读的密码套件名。
+func (ci *ConnInfo) CipherSuiteName() string {
+	if !ci.TLS {
+		return ""
+	}
+	return tls.CipherSuiteName(ci.CipherSuite)
+}
+
+// logFields 返回用于日志输出的键值对。
+func (ci *ConnInfo) logFields() []interface{} {
+	fields := []interface{}{
+		"proto", ci.Proto,
+		"remote_addr", ci.RemoteAddr,
+		"reused", ci.Reused,
+	}
+	if ci.TLS {
+		fields = append(fields,
+			"tls_version", ci.TLSVersionName(),
+			"cipher_suite", ci.CipherSuiteName(),
+			"alpn", ci.ALPN,
+			"tls_resumed", ci.DidResume,
+			"ja3", ci.JA3,
+		)
+		if len(ci.PeerCertificates) > 0 {
+			fields = append(fields, "peer_cert", ci.PeerCertificates[0].Subject.String())
+		}
+	}
+	return fields
+}
+
+// SetConnInfoLogging 开启后在请求日志中输出连接信息（TLS 版本、密码套件、ALPN、对端地址等）。
+func (h *HttpClient) SetConnInfoLogging(enable bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.logConnInfo = enable
+}
+
+// connInfoTrace 返回记录连接信息的 httptrace 钩子，每次重试都会覆盖为最新连接。
+func connInfoTrace(info *ConnInfo) *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GotConn: func(gci httptrace.GotConnInfo) {
+			*info = *newConnInfo(gci.Conn)
+			info.Reused = gci.Reused
+			info.WasIdle = gci.WasIdle
+		},
+	}
+}
+
+// newConnInfo 从连接（可能被包装）中提取地址与 TLS 状态。
+func newConnInfo(conn net.Conn) *ConnInfo {
+	ci := &ConnInfo{}
+	if conn == nil {
+		return ci
+	}
+	if addr := conn.RemoteAddr(); addr != nil {
+		ci.RemoteAddr = addr.String()
+	}
+	if addr := conn.LocalAddr(); addr != nil {
+		ci.LocalAddr = addr.String()
+	}
+	for conn != nil {
+		switch c := conn.(type) {
+		case *tls.Conn:
+			st := c.ConnectionState()
+			ci.setTLSState(&st)
+			return ci
+		case *utls.UConn:
+			st := stdTLSState(c.ConnectionState())
+			ci.setTLSState(&st)
+			ci.JA3 = true
+			return ci
+		case interface{ NetConn() net.Conn }:
+			conn = c.NetConn()
+		default:
+			return ci
+		}
+	}
+	return ci
+}
+
+// setTLSState 按标准库 TLS 连接状态填充 TLS 相关字段。
+func (ci *ConnInfo) setTLSState(st *tls.ConnectionState) {
+	ci.TLS = true
+	ci.TLSVersion = st.Version
+	ci.CipherSuite = st.CipherSuite
+	ci.ALPN = st.NegotiatedProtocol
+	ci.ServerName = st.ServerName
+	ci.DidResume = st.DidResume
+	ci.PeerCertificates = st.PeerCertificates
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestDo_ConnInfo_StandardTLS(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	c := NewHttpClient(ts.URL)
+	trustServer(t, c, ts, "")
+	resp, err := c.Do(&Request{Path: "/"})
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	ci := resp.Conn
+	if ci == nil || !ci.TLS || ci.JA3 {
+		t.Fatalf("expected standard TLS conn info, got %+v", ci)
+	}
+	if ci.TLSVersionName() == "" || ci.CipherSuiteName() == "" || len(ci.PeerCertificates) == 0 {
+		t.Fatalf("TLS details missing: %+v", ci)
+	}
+	if ci.RemoteAddr != ts.Listener.Addr().String() {
+		t.Fatalf("expected remote %s, got %s", ts.Listener.Addr(), ci.RemoteAddr)
+	}
+	if ci.Reused {
+		t.Fatal("first request should not reuse a connection")
+	}
+
+	resp, err = c.Do(&Request{Path: "/"})
+	if err != nil {
+		t.Fatalf("second Do failed: %v", err)
+	}
+	if !resp.Conn.Reused {
+		t.Fatal("second request should reuse the pooled connection")
+	}
+}
+
+func TestDo_ConnInfo_JA3(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	c := NewHttpClient(ts.URL)
+	trustServer(t, c, ts, "chrome")
+	resp, err := c.Do(&Request{Path: "/"})
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	ci := resp.Conn
+	if !ci.TLS || !ci.JA3 || ci.ALPN != "http/1.1" || ci.Proto != "HTTP/1.1" {
+		t.Fatalf("unexpected uTLS conn info: %+v", ci)
+	}
+}
+
+func TestDo_ConnInfo_PlainAndLogging(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	core, logs := observer.New(zap.InfoLevel)
+	c := NewHttpClient(ts.URL)
+	c.SetLogger(zap.New(core).Sugar())
+	c.SetConnInfoLogging(true)
+	resp, err := c.Do(&Request{Path: "/"})
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if resp.Conn.TLS || resp.Conn.RemoteAddr == "" {
+		t.Fatalf("unexpected plain conn info: %+v", resp.Conn)
+	}
+	found := false
+	for _, e := range logs.FilterMessage("请求成功").All() {
+		if addr, ok := e.ContextMap()["remote_addr"].(string); ok && strings.HasPrefix(addr, "127.0.0.1") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("conn info should be logged when enabled")
+	}
+}
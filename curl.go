@@ -0,0 +1,218 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// curlImpersonate JA3 profile 对应的 curl-impersonate 命令。curl 本身无法复现 uTLS 的 ClientHello，
+// 导出时以 --http1.1（uTLS 连接只协商 HTTP/1.1）加注释给出最接近的替代。
+var curlImpersonate = map[string]string{
+	"chrome":     "curl_chrome120",
+	"chrome-psk": "curl_chrome116",
+	"firefox":    "curl_ff102",
+	"safari":     "curl_safari15_5",
+	"edge":       "curl_edge101",
+	"ios":        "curl_safari15_5",
+}
+
+// ToCurl 将 r 渲染为可直接粘贴到 shell 的 curl 命令，包含合并后的 client/Session 请求头、
+// CookieJar 中的 cookie、body、代理、Unix socket、超时与证书校验设置；不发送请求，输出不脱敏。
+func (h *HttpClient) ToCurl(r *Request) (string, error) {
+	req, err := h.newRequest(r)
+	if err != nil {
+		return "", err
+	}
+	jar := h.jar
+	if r.Session != nil {
+		jar = r.Session.jar
+	}
+	return h.renderCurl(req, r.Body, jar, r.Session, nil), nil
+}
+
+// SetCurlLogging 开启后在请求结果日志中附加等价的 curl 命令（curl 字段，经脱敏）。
+func (h *HttpClient) SetCurlLogging(enable bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.logCurl = enable
+}
+
+// curlLogging 是否在日志中输出 curl 命令。
+func (h *HttpClient) curlLogging() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.logCurl
+}
+
+// curlForLog 生成日志中的 curl 命令，body 取原始请求体并脱敏。日志策略下 body 会被截断、
+// 省略或为二进制时不输出 --data，在命令末尾注明原因，避免给出内容残缺却可执行的命令。
+func (h *HttpClient) curlForLog(req *http.Request, body []byte, jar http.CookieJar, lg *requestLog) string {
+	if note := lg.curlBodyNote(body, req.Header.Get("Content-Type")); note != "" {
+		return h.renderCurl(req, nil, jar, nil, lg.rd) + " # " + note
+	}
+	return h.renderCurl(req, []byte(lg.rd.body(string(body))), jar, nil, lg.rd)
+}
+
+// renderCurl 生成 curl 命令；rd 非 nil 时对 URL、请求头、cookie 与代理密码脱敏（body 由调用方处理）。
+func (h *HttpClient) renderCurl(req *http.Request, body []byte, jar http.CookieJar, s *Session, rd *redactor) string {
+	h.mu.RLock()
+	proxyCfg, ja3, tlsCfg := h.proxyCfg, h.ja3Profile, h.tlsConfig
+	h.mu.RUnlock()
+	if s != nil {
+		if p := s.JA3Profile(); p != "" {
+			ja3 = p
+		}
+	}
+	order, names, preserve := h.headerLayout(s)
+
+	args := []string{"curl"}
+	hasBody := len(body) > 0
+	switch {
+	case req.Method == http.MethodHead:
+		args = append(args, "--head")
+	case req.Method == http.MethodGet && !hasBody, req.Method == http.MethodPost && hasBody:
+	default:
+		args = append(args, "-X", shellQuote(req.Method))
+	}
+	rawURL := req.URL.String()
+	if rd != nil {
+		rawURL = rd.url(rawURL)
+	}
+	args = append(args, shellQuote(rawURL))
+
+	header := req.Header.Clone()
+	stripWireLayout(header)
+	var cookies []string
+	if c := header.Get("Cookie"); c != "" {
+		cookies = append(cookies, c)
+		header.Del("Cookie")
+	}
+	if jar != nil {
+		for _, c := range jar.Cookies(req.URL) {
+			cookies = append(cookies, c.Name+"="+c.Value)
+		}
+	}
+	if rd != nil {
+		header = rd.header(header)
+	}
+	for _, k := range curlHeaderKeys(header, order) {
+		name := k
+		if n, ok := names[k]; ok && preserve {
+			name = n
+		}
+		for _, v := range header[k] {
+			args = append(args, "-H", shellQuote(name+": "+v))
+		}
+	}
+	if len(cookies) > 0 {
+		cookie := strings.Join(cookies, "; ")
+		if rd != nil && rd.headers["Cookie"] {
+			cookie = rd.replacement
+		}
+		args = append(args, "-b", shellQuote(cookie))
+	}
+
+	prefix := ""
+	if hasBody {
+		if bytes.IndexByte(body, 0) >= 0 {
+			// 命令行参数不能包含 NUL，经 stdin 传入
+			prefix = "printf '%b' " + shellQuote(printfEscape(body)) + " | "
+			args = append(args, "--data-binary", "@-")
+		} else {
+			args = append(args, "--data-raw", shellQuote(string(body)))
+		}
+	}
+	// 请求默认带 Accept-Encoding: gzip 并自动解压
+	args = append(args, "--compressed")
+
+	if proxyCfg != nil {
+		scheme := "http"
+		if proxyCfg.Type == "socks5" {
+			scheme = "socks5h"
+		}
+		pu := &url.URL{Scheme: scheme, Host: proxyCfg.Address}
+		if proxyCfg.Username != "" && proxyCfg.Password != "" {
+			pu.User = url.UserPassword(proxyCfg.Username, proxyCfg.Password)
+		}
+		proxy := pu.String()
+		if rd != nil {
+			proxy = rd.url(proxy)
+		}
+		args = append(args, "-x", shellQuote(proxy))
+	}
+	if path := h.unixSocketFor(urlDialAddr(req.URL)); path != "" {
+		args = append(args, "--unix-socket", shellQuote(path))
+	}
+	if tlsCfg != nil && tlsCfg.InsecureSkipVerify {
+		args = append(args, "-k")
+	}
+	if t := h.client.Timeout; t > 0 {
+		args = append(args, "--max-time", formatFloat(t.Seconds()))
+	}
+
+	cmd := prefix + strings.Join(args, " ")
+	if ja3 != "" {
+		cmd += " --http1.1 # ja3=" + ja3
+		if alt, ok := curlImpersonate[ja3]; ok {
+			cmd += ", closest curl-impersonate binary: " + alt
+		}
+	}
+	return cmd
+}
+
+// curlHeaderKeys 返回请求头输出顺序：先按 order，其余按名称排序。
+func curlHeaderKeys(header http.Header, order []string) []string {
+	keys := make([]string, 0, len(header))
+	seen := make(map[string]bool, len(header))
+	for _, n := range order {
+		k := textproto.CanonicalMIMEHeaderKey(n)
+		if _, ok := header[k]; ok && !seen[k] {
+			keys = append(keys, k)
+			seen[k] = true
+		}
+	}
+	rest := make([]string, 0, len(header))
+	for k := range header {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+// shellQuote 用单引号包裹 s，适用于 POSIX shell。
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	safe := true
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("-_./:=@,+%", c)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// printfEscape 将字节转为 printf %b 可还原的形式：可打印 ASCII 原样保留，其余转为八进制。
+func printfEscape(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		if c >= 0x20 && c < 0x7f && c != '\\' {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, `\0%03o`, c)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,552 @@
+package client
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// curlIgnoredValueFlags 带参数、只影响 curl 自身输出/超时/重试的选项，解析时忽略。
+const curlIgnoredValueFlags = "-o --output -m --max-time --connect-timeout --retry --retry-delay --retry-max-time " +
+	"-w --write-out -c --cookie-jar --max-redirs"
+
+var (
+	// curlValueFlags 需要参数的 curl 选项。
+	curlValueFlags = curlFlagSet("-X --request -H --header -d --data --data-ascii --data-binary --data-raw --data-urlencode " +
+		"-F --form --form-string -b --cookie -u --user -x --proxy -U --proxy-user -A --user-agent -e --referer --url " +
+		curlIgnoredValueFlags)
+	// curlIgnoredFlags 不影响请求内容、解析时忽略的 curl 选项。
+	curlIgnoredFlags = curlFlagSet("-s --silent -S --show-error -L --location -v --verbose -i --include -f --fail " +
+		"-g --globoff -N --no-buffer -# --progress-bar --no-progress-meter --path-as-is " +
+		"--http1.1 --http2 --http2-prior-knowledge --http3 " + curlIgnoredValueFlags)
+)
+
+func curlFlagSet(flags string) map[string]bool {
+	m := make(map[string]bool)
+	for _, f := range strings.Fields(flags) {
+		m[f] = true
+	}
+	return m
+}
+
+// curlFormField 一个 -F / --form-string 参数。
+type curlFormField struct {
+	spec    string // name=value
+	literal bool   // --form-string：值不解析 @ 与 <
+}
+
+// ParseCurl 解析 curl 命令行（如浏览器 DevTools 的 "Copy as cURL"），支持 -X、-H、-d/--data-raw/--data-binary、
+// --data-urlencode、-F、-b、-u、-x、-A、-e、-G、-I、-k 与 --compressed，兼容 bash 的单/双引号、$'...' 与续行。
+// 无法忠实还原的选项（如读取 stdin、cookie 文件）返回错误，仅影响 curl 自身输出的选项被忽略。
+func ParseCurl(cmd string) (*CurlCommand, error) {
+	args, err := splitShellWords(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 || !strings.HasPrefix(filepath.Base(args[0]), "curl") {
+		return nil, errors.New("curl: not a curl command")
+	}
+
+	var (
+		out       = &CurlCommand{}
+		header    = make(http.Header)
+		method    string
+		rawURL    string
+		data      []string
+		form      []curlFormField
+		cookies   []string
+		user      string
+		proxyUser string
+		get, head bool
+	)
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if arg == "" || arg[0] != '-' || arg == "-" {
+			if rawURL != "" {
+				return nil, errors.New("curl: multiple URLs are not supported")
+			}
+			rawURL = arg
+			continue
+		}
+		name, val, hasVal := arg, "", false
+		if len(arg) > 2 && arg[1] != '-' {
+			if curlValueFlags[arg[:2]] {
+				// -XPOST
+				name, val, hasVal = arg[:2], arg[2:], true
+			} else {
+				// -sSL 拆为 -s -SL
+				name = arg[:2]
+				args = slices.Insert(args, i+1, "-"+arg[2:])
+			}
+		}
+		if curlValueFlags[name] && !hasVal {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("curl: option %s requires a value", name)
+			}
+			i++
+			val = args[i]
+		}
+
+		switch name {
+		case "-X", "--request":
+			method = val
+		case "-H", "--header":
+			if err := addCurlHeader(header, val); err != nil {
+				return nil, err
+			}
+		case "-d", "--data", "--data-ascii", "--data-binary":
+			v, err := readCurlData(val, name != "--data-binary")
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, v)
+		case "--data-raw":
+			data = append(data, val)
+		case "--data-urlencode":
+			v, err := encodeCurlData(val)
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, v)
+		case "-F", "--form":
+			form = append(form, curlFormField{spec: val})
+		case "--form-string":
+			form = append(form, curlFormField{spec: val, literal: true})
+		case "-b", "--cookie":
+			if !strings.Contains(val, "=") {
+				return nil, fmt.Errorf("curl: cookie file %q is not supported", val)
+			}
+			cookies = append(cookies, val)
+		case "-u", "--user":
+			user = val
+		case "-x", "--proxy":
+			if out.Proxy, err = parseCurlProxy(val); err != nil {
+				return nil, err
+			}
+		case "-U", "--proxy-user":
+			proxyUser = val
+		case "-A", "--user-agent":
+			header.Set("User-Agent", val)
+		case "-e", "--referer":
+			header.Set("Referer", val)
+		case "--url":
+			if rawURL != "" {
+				return nil, errors.New("curl: multiple URLs are not supported")
+			}
+			rawURL = val
+		case "-G", "--get":
+			get = true
+		case "-I", "--head":
+			head = true
+		case "-k", "--insecure":
+			out.Insecure = true
+		case "--compressed":
+			out.Compressed = true
+		default:
+			if !curlIgnoredFlags[name] {
+				return nil, fmt.Errorf("curl: unsupported option %s", name)
+			}
+		}
+	}
+
+	if rawURL == "" {
+		return nil, errors.New("curl: no URL specified")
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("curl: invalid URL: %w", err)
+	}
+
+	var body []byte
+	defaultMethod := http.MethodGet
+	switch {
+	case len(form) > 0:
+		if len(data) > 0 {
+			return nil, errors.New("curl: -F cannot be combined with -d")
+		}
+		var contentType string
+		if body, contentType, err = buildCurlForm(form); err != nil {
+			return nil, err
+		}
+		header.Set("Content-Type", contentType)
+		defaultMethod = http.MethodPost
+	case len(data) > 0 && get:
+		// -G 将数据拼接到查询串
+		if u.RawQuery != "" {
+			u.RawQuery += "&"
+		}
+		u.RawQuery += strings.Join(data, "&")
+	case len(data) > 0:
+		body = []byte(strings.Join(data, "&"))
+		if header.Get("Content-Type") == "" {
+			header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		defaultMethod = http.MethodPost
+	}
+	if head {
+		defaultMethod = http.MethodHead
+	}
+	if method == "" {
+		method = defaultMethod
+	}
+
+	if len(cookies) > 0 {
+		if c := header.Get("Cookie"); c != "" {
+			cookies = append([]string{c}, cookies...)
+		}
+		header.Set("Cookie", strings.Join(cookies, "; "))
+	}
+	if user != "" {
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(user)))
+	}
+	if proxyUser != "" && out.Proxy != nil {
+		out.Proxy.Username, out.Proxy.Password, _ = strings.Cut(proxyUser, ":")
+	}
+
+	out.Request = &Request{Method: method, Path: u.String(), Header: header, Body: body}
+	return out, nil
+}
+
+// ParseCurl 解析 curl 命令，cc.Request 可直接交给 h.Do。解析不修改 h：-x 代理与 -k 只记录在结果中，
+// 由调用方决定是否 SetProxy（client 级设置，影响其它请求）。
+func (h *HttpClient) ParseCurl(cmd string) (*CurlCommand, error) {
+	return h.ParseCurlWithSession(nil, cmd)
+}
+
+// ParseCurlWithSession 同 ParseCurl，请求使用 s 的 CookieJar 与请求头，-b 的 cookie 写入 s 的 CookieJar。
+func (h *HttpClient) ParseCurlWithSession(s *Session, cmd string) (*CurlCommand, error) {
+	cc, err := ParseCurl(cmd)
+	if err != nil {
+		return nil, err
+	}
+	r := cc.Request
+	if s != nil {
+		r.Session = s
+		if line := r.Header.Get("Cookie"); line != "" {
+			if parsed, err := http.ParseCookie(line); err == nil {
+				cookies := make(map[string]string, len(parsed))
+				for _, c := range parsed {
+					cookies[c.Name] = c.Value
+				}
+				s.SetCookies(r.Path, cookies)
+				r.Header.Del("Cookie")
+			}
+		}
+	}
+	return cc, nil
+}
+
+// addCurlHeader 按 curl 语义处理 -H："Name: value" 追加，"Name:" 删除，"Name;" 发送空值。
+func addCurlHeader(header http.Header, line string) error {
+	if strings.HasPrefix(line, "@") {
+		return fmt.Errorf("curl: header file %q is not supported", line[1:])
+	}
+	if name, value, ok := strings.Cut(line, ":"); ok {
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if value == "" {
+			header.Del(name)
+		} else {
+			header.Add(name, value)
+		}
+		return nil
+	}
+	if name, rest, ok := strings.Cut(line, ";"); ok && strings.TrimSpace(rest) == "" {
+		header.Add(strings.TrimSpace(name), "")
+		return nil
+	}
+	return fmt.Errorf("curl: invalid header %q", line)
+}
+
+// readCurlData 处理 -d/--data-binary 的参数，@file 读取文件内容；strip 为 true 时同 curl -d 去掉换行。
+func readCurlData(val string, strip bool) (string, error) {
+	if !strings.HasPrefix(val, "@") {
+		return val, nil
+	}
+	path := val[1:]
+	if path == "-" {
+		return "", errors.New("curl: reading data from stdin is not supported")
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("curl: %w", err)
+	}
+	s := string(b)
+	if strip {
+		s = strings.NewReplacer("\r", "", "\n", "").Replace(s)
+	}
+	return s, nil
+}
+
+// encodeCurlData 处理 --data-urlencode 的 content、=content、name=content、@file 与 name@file 形式。
+func encodeCurlData(val string) (string, error) {
+	name, content := "", val
+	if i := strings.IndexAny(val, "=@"); i >= 0 {
+		name, content = val[:i], val[i+1:]
+		if val[i] == '@' {
+			if content == "-" {
+				return "", errors.New("curl: reading data from stdin is not supported")
+			}
+			b, err := os.ReadFile(content)
+			if err != nil {
+				return "", fmt.Errorf("curl: %w", err)
+			}
+			content = string(b)
+		}
+	}
+	// curl 将空格编码为 %20
+	enc := strings.ReplaceAll(url.QueryEscape(content), "+", "%20")
+	if name == "" {
+		return enc, nil
+	}
+	return name + "=" + enc, nil
+}
+
+// buildCurlForm 将 -F 参数编码为 multipart/form-data，返回 body 与 Content-Type。
+// 支持 name=value、name=@file[;type=...][;filename=...] 与 name=<file。
+func buildCurlForm(fields []curlFormField) ([]byte, string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	quote := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	for _, f := range fields {
+		name, value, ok := strings.Cut(f.spec, "=")
+		if !ok {
+			return nil, "", fmt.Errorf("curl: invalid form field %q", f.spec)
+		}
+		switch {
+		case !f.literal && strings.HasPrefix(value, "@"):
+			opts := strings.Split(value[1:], ";")
+			path, filename, contentType := opts[0], filepath.Base(opts[0]), "application/octet-stream"
+			for _, o := range opts[1:] {
+				k, v, _ := strings.Cut(o, "=")
+				switch strings.TrimSpace(k) {
+				case "type":
+					contentType = v
+				case "filename":
+					filename = strings.Trim(v, `"`)
+				}
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil, "", fmt.Errorf("curl: %w", err)
+			}
+			hdr := make(textproto.MIMEHeader)
+			hdr.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, quote.Replace(name), quote.Replace(filename)))
+			hdr.Set("Content-Type", contentType)
+			part, err := w.CreatePart(hdr)
+			if err != nil {
+				return nil, "", err
+			}
+			if _, err := part.Write(content); err != nil {
+				return nil, "", err
+			}
+		case !f.literal && strings.HasPrefix(value, "<"):
+			content, err := os.ReadFile(strings.Split(value[1:], ";")[0])
+			if err != nil {
+				return nil, "", fmt.Errorf("curl: %w", err)
+			}
+			if err := w.WriteField(name, string(content)); err != nil {
+				return nil, "", err
+			}
+		default:
+			if err := w.WriteField(name, value); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), w.FormDataContentType(), nil
+}
+
+// parseCurlProxy 解析 -x 的代理地址，未写协议时按 HTTP 代理处理，未写端口时使用 1080。
+func parseCurlProxy(v string) (*ProxyConfig, error) {
+	if !strings.Contains(v, "://") {
+		v = "http://" + v
+	}
+	u, err := url.Parse(v)
+	if err != nil {
+		return nil, fmt.Errorf("curl: invalid proxy: %w", err)
+	}
+	cfg := &ProxyConfig{Address: u.Host}
+	switch u.Scheme {
+	case "http":
+		cfg.Type = "http"
+	case "socks5", "socks5h":
+		cfg.Type = "socks5"
+	default:
+		return nil, fmt.Errorf("curl: unsupported proxy scheme %q", u.Scheme)
+	}
+	if u.Port() == "" {
+		cfg.Address = u.Host + ":1080"
+	}
+	if u.User != nil {
+		cfg.Username = u.User.Username()
+		cfg.Password, _ = u.User.Password()
+	}
+	return cfg, nil
+}
+
+// splitShellWords 按 bash 规则切分命令行：支持单引号、双引号、$'...'、反斜杠转义、续行与 # 注释。
+func splitShellWords(s string) ([]string, error) {
+	var (
+		words  []string
+		cur    strings.Builder
+		inWord bool
+	)
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\':
+			if strings.HasPrefix(s[i+1:], "\n") {
+				i += 2
+				continue
+			}
+			if strings.HasPrefix(s[i+1:], "\r\n") {
+				i += 3
+				continue
+			}
+			if i+1 >= len(s) {
+				return nil, errors.New("curl: trailing backslash")
+			}
+			cur.WriteByte(s[i+1])
+			inWord = true
+			i += 2
+		case c == '\'':
+			j := strings.IndexByte(s[i+1:], '\'')
+			if j < 0 {
+				return nil, errors.New("curl: unterminated single quote")
+			}
+			cur.WriteString(s[i+1 : i+1+j])
+			inWord = true
+			i += j + 2
+		case c == '$' && strings.HasPrefix(s[i+1:], "'"):
+			n, err := readANSICQuote(s[i+2:], &cur)
+			if err != nil {
+				return nil, err
+			}
+			inWord = true
+			i += 2 + n
+		case c == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`\n", s[i+1]) >= 0 {
+					i++
+					if s[i] == '\n' {
+						continue
+					}
+				}
+				cur.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, errors.New("curl: unterminated double quote")
+			}
+			inWord = true
+			i++
+		case c == '#' && !inWord:
+			// 注释到行尾（ToCurl 输出的 JA3 提示）
+			if j := strings.IndexByte(s[i:], '\n'); j >= 0 {
+				i += j
+			} else {
+				i = len(s)
+			}
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inWord {
+				words = append(words, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+			i++
+		default:
+			cur.WriteByte(c)
+			inWord = true
+			i++
+		}
+	}
+	if inWord {
+		words = append(words, cur.String())
+	}
+	return words, nil
+}
+
+// readANSICQuote 解码 $'...' 的内容（s 从开引号之后开始），返回消耗的字节数（含闭引号）。
+func readANSICQuote(s string, sb *strings.Builder) (int, error) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\'' {
+			return i + 1, nil
+		}
+		if c != '\\' || i+1 >= len(s) {
+			sb.WriteByte(c)
+			continue
+		}
+		i++
+		switch e := s[i]; e {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'a':
+			sb.WriteByte('\a')
+		case 'b':
+			sb.WriteByte('\b')
+		case 'e', 'E':
+			sb.WriteByte(0x1b)
+		case 'f':
+			sb.WriteByte('\f')
+		case 'v':
+			sb.WriteByte('\v')
+		case '\\', '\'', '"', '?':
+			sb.WriteByte(e)
+		case 'x', 'u', 'U':
+			width := map[byte]int{'x': 2, 'u': 4, 'U': 8}[e]
+			j := i + 1
+			for j < len(s) && j-i-1 < width && isHexDigit(s[j]) {
+				j++
+			}
+			if j == i+1 {
+				sb.WriteByte('\\')
+				sb.WriteByte(e)
+				continue
+			}
+			v, _ := strconv.ParseUint(s[i+1:j], 16, 32)
+			if e == 'x' {
+				sb.WriteByte(byte(v))
+			} else {
+				sb.WriteRune(rune(v))
+			}
+			i = j - 1
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			j := i
+			for j < len(s) && j-i < 3 && s[j] >= '0' && s[j] <= '7' {
+				j++
+			}
+			v, _ := strconv.ParseUint(s[i:j], 8, 16)
+			sb.WriteByte(byte(v))
+			i = j - 1
+		default:
+			sb.WriteByte('\\')
+			sb.WriteByte(e)
+		}
+	}
+	return 0, errors.New("curl: unterminated $'...' quote")
+}
+
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
@@ -0,0 +1,232 @@
+package client
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseCurl_DevToolsCommand(t *testing.T) {
+	// Chrome "Copy as cURL (bash)" 的典型输出
+	cmd := `curl 'https://api.example.com/login?from=web' \
+  -H 'accept: application/json' \
+  -H 'content-type: application/json' \
+  -b 'sid=abc; lang=zh' \
+  -H $'x-note: it\'s \u4e2d\x41' \
+  --data-raw $'{"user":"tom","pass":"p\'w"}' \
+  --compressed`
+	cc, err := ParseCurl(cmd)
+	if err != nil {
+		t.Fatalf("ParseCurl failed: %v", err)
+	}
+	r := cc.Request
+	if r.Method != http.MethodPost || r.Path != "https://api.example.com/login?from=web" {
+		t.Fatalf("unexpected request line: %s %s", r.Method, r.Path)
+	}
+	if got := string(r.Body); got != `{"user":"tom","pass":"p'w"}` {
+		t.Fatalf("unexpected body: %s", got)
+	}
+	for name, want := range map[string]string{
+		"Accept":       "application/json",
+		"Content-Type": "application/json",
+		"Cookie":       "sid=abc; lang=zh",
+		"X-Note":       "it's 中A",
+	} {
+		if got := r.Header.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if !cc.Compressed || cc.Proxy != nil || cc.Insecure {
+		t.Fatalf("unexpected flags: %+v", cc)
+	}
+}
+
+func TestParseCurl_Options(t *testing.T) {
+	for _, tc := range []struct {
+		cmd    string
+		method string
+		path   string
+		body   string
+		header map[string]string
+	}{
+		{
+			cmd:    `curl example.com/a -d a=1 -d "b=2 3"`,
+			method: "POST", path: "http://example.com/a", body: "a=1&b=2 3",
+			header: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+		},
+		{
+			cmd:    `curl -G https://x.io/s?q=1 --data-urlencode "kw=a b&c" --data-urlencode =z`,
+			method: "GET", path: "https://x.io/s?q=1&kw=a%20b%26c&z",
+		},
+		{
+			cmd:    `curl -sSL -XPUT -u admin:secret -A ua/1 -e https://ref/ https://x.io/r`,
+			method: "PUT", path: "https://x.io/r",
+			header: map[string]string{"Authorization": "Basic YWRtaW46c2VjcmV0", "User-Agent": "ua/1", "Referer": "https://ref/"},
+		},
+		{
+			cmd:    `curl -I --url https://x.io/h -H "X-Empty;" -H "Accept:"`,
+			method: "HEAD", path: "https://x.io/h",
+			header: map[string]string{"X-Empty": ""},
+		},
+	} {
+		cc, err := ParseCurl(tc.cmd)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.cmd, err)
+		}
+		r := cc.Request
+		if r.Method != tc.method || r.Path != tc.path || string(r.Body) != tc.body {
+			t.Errorf("%s: got %s %s %q", tc.cmd, r.Method, r.Path, r.Body)
+		}
+		for k, v := range tc.header {
+			if vals, ok := r.Header[http.CanonicalHeaderKey(k)]; !ok || vals[0] != v {
+				t.Errorf("%s: header %s = %v, want %q", tc.cmd, k, vals, v)
+			}
+		}
+	}
+
+	cc, err := ParseCurl(`curl -k -x user:pw@10.0.0.1 -U u2:p2 https://x.io`)
+	if err != nil {
+		t.Fatalf("ParseCurl failed: %v", err)
+	}
+	if !cc.Insecure || *cc.Proxy != (ProxyConfig{Type: "http", Address: "10.0.0.1:1080", Username: "u2", Password: "p2"}) {
+		t.Fatalf("unexpected proxy/insecure: %+v %+v", cc, cc.Proxy)
+	}
+	if cc, _ := ParseCurl(`curl --proxy socks5h://127.0.0.1:9050 https://x.io`); cc.Proxy.Type != "socks5" {
+		t.Fatalf("unexpected proxy: %+v", cc.Proxy)
+	}
+}
+
+func TestParseCurl_Form(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	os.WriteFile(path, []byte("file content"), 0o644)
+
+	cc, err := ParseCurl(`curl https://x.io/up -F name=tom -F "doc=@` + path + `;type=text/plain" -F note=<` + path + ` --form-string "raw=@keep"`)
+	if err != nil {
+		t.Fatalf("ParseCurl failed: %v", err)
+	}
+	r := cc.Request
+	if r.Method != http.MethodPost {
+		t.Fatalf("unexpected method: %s", r.Method)
+	}
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("bad content type: %v", err)
+	}
+	mr := multipart.NewReader(strings.NewReader(string(r.Body)), params["boundary"])
+	got := map[string]string{}
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextPart: %v", err)
+		}
+		b, _ := io.ReadAll(p)
+		key := p.FormName()
+		if p.FileName() != "" {
+			key += "|" + p.FileName() + "|" + p.Header.Get("Content-Type")
+		}
+		got[key] = string(b)
+	}
+	want := map[string]string{
+		"name":                 "tom",
+		"doc|a.txt|text/plain": "file content",
+		"note":                 "file content",
+		"raw":                  "@keep",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("part %s = %q, want %q (all: %v)", k, got[k], v, got)
+		}
+	}
+}
+
+func TestParseCurl_Errors(t *testing.T) {
+	for _, cmd := range []string{
+		`wget https://x.io`,
+		`curl`,
+		`curl https://x.io -H`,
+		`curl https://x.io 'unterminated`,
+		`curl https://x.io --data-binary @-`,
+		`curl https://x.io -b cookies.txt`,
+		`curl https://x.io --unknown-flag`,
+		`curl https://x.io -x ftp://p:1`,
+		`curl https://x.io -F a=1 -d b=2`,
+	} {
+		if _, err := ParseCurl(cmd); err == nil {
+			t.Errorf("%s: expected error", cmd)
+		}
+	}
+}
+
+func TestParseCurl_ToCurlRoundTrip(t *testing.T) {
+	c := NewHttpClient("https://api.example.com")
+	c.SetHeader(map[string]string{"X-Token": `a'b"c`})
+	if err := c.EnableJA3("firefox"); err != nil {
+		t.Fatalf("EnableJA3 failed: %v", err)
+	}
+	orig := &Request{Method: http.MethodPatch, Path: "/v1/items?id=7", Body: []byte("name=o'neil&x=$HOME")}
+	cmd, err := c.ToCurl(orig)
+	if err != nil {
+		t.Fatalf("ToCurl failed: %v", err)
+	}
+	cc, err := ParseCurl(cmd)
+	if err != nil {
+		t.Fatalf("ParseCurl(%s) failed: %v", cmd, err)
+	}
+	r := cc.Request
+	if r.Method != http.MethodPatch || r.Path != "https://api.example.com/v1/items?id=7" || string(r.Body) != string(orig.Body) {
+		t.Fatalf("round trip mismatch: %s %s %q", r.Method, r.Path, r.Body)
+	}
+	if r.Header.Get("X-Token") != `a'b"c` {
+		t.Fatalf("header lost: %v", r.Header)
+	}
+}
+
+func TestHttpClient_ParseCurlWithSession(t *testing.T) {
+	var gotCookie, gotUA string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotCookie, gotUA = r.Header.Get("Cookie"), r.Header.Get("User-Agent")
+	}))
+	defer ts.Close()
+
+	c := NewHttpClient(ts.URL)
+	s := NewSession()
+	cc, err := c.ParseCurlWithSession(s, `curl '`+ts.URL+`/p' -b 'sid=abc' -A 'pasted/1.0'`)
+	if err != nil {
+		t.Fatalf("ParseCurlWithSession failed: %v", err)
+	}
+	if _, err := c.Do(cc.Request); err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if gotCookie != "sid=abc" || gotUA != "pasted/1.0" {
+		t.Fatalf("unexpected request: cookie=%q ua=%q", gotCookie, gotUA)
+	}
+	// -b 写入 Session 的 CookieJar，后续请求自动携带
+	if s.GetCookieValue(ts.URL, "sid") != "abc" {
+		t.Fatal("cookie should be stored in the session jar")
+	}
+
+	// 代理只返回给调用方，不修改 client
+	cc, err = c.ParseCurl(`curl -x socks5://127.0.0.1:1 ` + ts.URL)
+	if err != nil {
+		t.Fatalf("ParseCurl failed: %v", err)
+	}
+	if cc.Proxy == nil || cc.Proxy.Type != "socks5" {
+		t.Fatalf("proxy should be returned: %+v", cc.Proxy)
+	}
+	if c.proxyCfg != nil {
+		t.Fatalf("ParseCurl should not change the client proxy: %+v", c.proxyCfg)
+	}
+	if _, err := c.Do(cc.Request); err != nil {
+		t.Fatalf("request should still go direct: %v", err)
+	}
+}
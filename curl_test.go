@@ -0,0 +1,174 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestToCurl_MergesClientState(t *testing.T) {
+	c := NewHttpClient("https://api.example.com")
+	c.SetHeader(map[string]string{"User-Agent": "req-test", "Authorization": "Bearer t0k"})
+	c.SetCookiesFor("https://api.example.com", map[string]string{"sid": "abc"})
+	c.SetTimeout(1500 * time.Millisecond)
+	if err := c.SetProxy(&ProxyConfig{Type: "socks5", Address: "127.0.0.1:1080", Username: "u", Password: "p"}); err != nil {
+		t.Fatalf("SetProxy failed: %v", err)
+	}
+
+	s := NewSession()
+	s.SetHeader("X-Session", "it's")
+	s.SetCookies("https://api.example.com", map[string]string{"uid": "42"})
+	cmd, err := c.ToCurl(&Request{
+		Method:  http.MethodPut,
+		Path:    "/items?q=a%20b",
+		Header:  http.Header{"Content-Type": {"application/json"}},
+		Body:    []byte(`{"name":"o'neil"}`),
+		Session: s,
+	})
+	if err != nil {
+		t.Fatalf("ToCurl failed: %v", err)
+	}
+	for _, want := range []string{
+		"curl -X PUT 'https://api.example.com/items?q=a%20b'",
+		`-H 'Authorization: Bearer t0k'`,
+		`-H 'X-Session: it'\''s'`,
+		`-H 'Content-Type: application/json'`,
+		`-b uid=42`,
+		`--data-raw '{"name":"o'\''neil"}'`,
+		"--compressed",
+		"-x socks5h://u:p@127.0.0.1:1080",
+		"--max-time 1.5",
+	} {
+		if !strings.Contains(cmd, want) {
+			t.Errorf("missing %q in:\n%s", want, cmd)
+		}
+	}
+	// Session 使用独立的 CookieJar
+	if strings.Contains(cmd, "sid=abc") {
+		t.Errorf("client cookie leaked into session request:\n%s", cmd)
+	}
+}
+
+func TestToCurl_MethodsAndBinaryBody(t *testing.T) {
+	c := NewHttpClient("http://localhost")
+	c.SetTimeout(0)
+	c.SetHeader(map[string]string{"User-Agent": "req-test"})
+	const hdr = " -H 'Content-Type: application/x-www-form-urlencoded' -H 'User-Agent: req-test'"
+	for _, tc := range []struct {
+		req  *Request
+		want string
+	}{
+		{&Request{Path: "/"}, "curl http://localhost/" + hdr + " --compressed"},
+		{&Request{Method: http.MethodHead, Path: "/"}, "curl --head http://localhost/" + hdr + " --compressed"},
+		{&Request{Method: http.MethodPost, Path: "/", Body: []byte("a=1")}, "curl http://localhost/" + hdr + " --data-raw a=1 --compressed"},
+		{&Request{Method: http.MethodPost, Path: "/", Body: []byte{'a', 0, '\\', 0xff}}, `printf '%b' 'a\0000\0134\0377' | curl http://localhost/` + hdr + ` --data-binary @- --compressed`},
+	} {
+		got, err := c.ToCurl(tc.req)
+		if err != nil {
+			t.Fatalf("ToCurl failed: %v", err)
+		}
+		if got != tc.want {
+			t.Errorf("got  %s\nwant %s", got, tc.want)
+		}
+	}
+
+	if err := c.EnableJA3("chrome"); err != nil {
+		t.Fatalf("EnableJA3 failed: %v", err)
+	}
+	got, _ := c.ToCurl(&Request{Path: "/"})
+	if !strings.HasSuffix(got, "--http1.1 # ja3=chrome, closest curl-impersonate binary: curl_chrome120") {
+		t.Errorf("unexpected ja3 hint: %s", got)
+	}
+}
+
+func TestToCurl_ShellRoundTrip(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+	for _, s := range []string{"plain", "it's", `a"b$c\d`, "tab\there", ""} {
+		out, err := exec.Command(sh, "-c", "printf %s "+shellQuote(s)).Output()
+		if err != nil {
+			t.Fatalf("sh failed: %v", err)
+		}
+		if string(out) != s {
+			t.Errorf("shellQuote(%q) round-tripped to %q", s, out)
+		}
+	}
+	body := []byte{0, 1, '%', '\\', '\'', 0xfe}
+	out, err := exec.Command(sh, "-c", "printf '%b' "+shellQuote(printfEscape(body))).Output()
+	if err != nil {
+		t.Fatalf("sh failed: %v", err)
+	}
+	if string(out) != string(body) {
+		t.Errorf("printfEscape round-tripped to %q", out)
+	}
+}
+
+func TestCurlLogging_Redacted(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	core, logs := observer.New(zap.DebugLevel)
+	c := NewHttpClient(ts.URL)
+	c.SetLogger(zap.New(core).Sugar())
+	c.SetHeader(map[string]string{"Authorization": "Bearer secret"})
+	c.Do(&Request{Path: "/?token=abc"})
+	if _, ok := logs.FilterMessage("请求返回非成功状态").All()[0].ContextMap()["curl"]; ok {
+		t.Fatal("curl field should be off by default")
+	}
+
+	c.SetCurlLogging(true)
+	c.Do(&Request{Path: "/?token=abc"})
+	entries := logs.FilterMessage("请求返回非成功状态").All()
+	cmd, _ := entries[len(entries)-1].ContextMap()["curl"].(string)
+	if !strings.HasPrefix(cmd, "curl ") {
+		t.Fatalf("curl command missing: %v", entries[len(entries)-1].ContextMap())
+	}
+	if strings.Contains(cmd, "secret") || strings.Contains(cmd, "abc") {
+		t.Fatalf("curl command not redacted: %s", cmd)
+	}
+}
+
+func TestCurlLogging_Body(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	core, logs := observer.New(zap.DebugLevel)
+	c := NewHttpClient(ts.URL)
+	c.SetLogger(zap.New(core).Sugar())
+	c.SetCurlLogging(true)
+	c.SetLogPolicy(&LogPolicy{MaxBodyBytes: 64})
+	lastCurl := func(body []byte, contentType string) string {
+		c.Do(&Request{Method: "POST", Path: "/", Body: body, Header: http.Header{"Content-Type": {contentType}}})
+		entries := logs.FilterMessage("请求返回非成功状态").All()
+		cmd, _ := entries[len(entries)-1].ContextMap()["curl"].(string)
+		return cmd
+	}
+
+	cmd := lastCurl([]byte(`{"password":"pw1","name":"tom"}`), "application/json")
+	if !strings.Contains(cmd, "--data-raw") || !strings.Contains(cmd, "tom") || strings.Contains(cmd, "pw1") {
+		t.Fatalf("body should be rendered from the redacted request body: %s", cmd)
+	}
+
+	long := `{"name":"` + strings.Repeat("x", 100) + `"}`
+	cmd = lastCurl([]byte(long), "application/json")
+	if strings.Contains(cmd, "--data") || strings.Contains(cmd, "truncated") || !strings.Contains(cmd, "# body omitted, 111 bytes") {
+		t.Fatalf("truncated body should be omitted with a note: %s", cmd)
+	}
+
+	cmd = lastCurl([]byte{0x89, 'P', 'N', 'G', 0}, "image/png")
+	if strings.Contains(cmd, "--data") || !strings.HasSuffix(cmd, "# binary body omitted, 5 bytes") {
+		t.Fatalf("binary body should be omitted with a note: %s", cmd)
+	}
+}
@@ -0,0 +1,554 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// IP 版本偏好，见 TransportConfig.PreferIP。
+const (
+	PreferIPv4 = "ipv4"
+	PreferIPv6 = "ipv6"
+)
+
+// Resolver 主机名解析接口，*net.Resolver 即满足该接口。
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// ttlUnknown 表示解析结果不带 TTL（如系统解析），此时按 DNSCacheTTL 缓存；记录 TTL 为 0 时不缓存。
+const ttlUnknown time.Duration = -1
+
+// ttlResolver 可同时返回记录 TTL 的解析器，DNS 缓存会据此确定过期时间；TTL 未知时返回 ttlUnknown。
+type ttlResolver interface {
+	lookupIPTTL(ctx context.Context, host string) ([]net.IP, time.Duration, error)
+}
+
+// DNSStats DNS 缓存统计。
+type DNSStats struct {
+	Hits    uint64 // 命中缓存（含静态覆盖）的次数
+	Misses  uint64 // 实际发起解析的次数
+	Errors  uint64 // 解析失败次数
+	Entries int    // 当前缓存条目数
+}
+
+// dnsResolver 统一处理静态覆盖、自定义解析器、缓存与 IP 版本偏好。
+type dnsResolver struct {
+	overrides map[string][]net.IP
+	resolver  Resolver
+	prefer    string
+	ttl       time.Duration // 缓存 TTL 上限，0 表示不缓存
+
+	mu        sync.Mutex
+	cache     map[string]dnsEntry
+	inflight  map[string]*dnsCall
+	lastSweep time.Time // 上次清理过期条目的时间
+
+	hits, misses, errors atomic.Uint64
+}
+
+type dnsEntry struct {
+	ips     []net.IP
+	expires time.Time
+}
+
+// dnsCall 合并同一主机名的并发解析。
+type dnsCall struct {
+	done chan struct{}
+	ips  []net.IP
+	ttl  time.Duration // 记录 TTL，未知时为 ttlUnknown
+	err  error
+}
+
+// newDNSResolver 根据传输配置创建解析器；未配置任何 DNS 选项时返回 nil，沿用系统解析。
+// 存在无效选项时返回忽略这些选项后的解析器及错误。
+func newDNSResolver(tc *TransportConfig) (*dnsResolver, error) {
+	if tc == nil || (len(tc.Resolve) == 0 && tc.DNSServer == "" && tc.Resolver == nil &&
+		tc.DNSCacheTTL <= 0 && tc.PreferIP == "") {
+		return nil, nil
+	}
+	var errs []error
+	prefer := tc.PreferIP
+	switch prefer {
+	case "", PreferIPv4, PreferIPv6:
+	default:
+		errs = append(errs, fmt.Errorf("unsupported PreferIP: %s", prefer))
+		prefer = ""
+	}
+	r := &dnsResolver{
+		overrides: make(map[string][]net.IP),
+		resolver:  tc.Resolver,
+		prefer:    prefer,
+		ttl:       tc.DNSCacheTTL,
+		cache:     make(map[string]dnsEntry),
+		inflight:  make(map[string]*dnsCall),
+	}
+	for host, addrs := range tc.Resolve {
+		for _, a := range strings.Split(addrs, ",") {
+			ip := net.ParseIP(strings.TrimSpace(a))
+			if ip == nil {
+				errs = append(errs, fmt.Errorf("invalid IP %q for host %s", a, host))
+				continue
+			}
+			key := strings.ToLower(host)
+			r.overrides[key] = append(r.overrides[key], ip)
+		}
+	}
+	if r.resolver == nil && tc.DNSServer != "" {
+		r.resolver = NewDNSServerResolver(tc.DNSServer)
+	}
+	if r.resolver == nil {
+		r.resolver = net.DefaultResolver
+	}
+	return r, errors.Join(errs...)
+}
+
+// lookup 解析主机名，按偏好排序后返回。
+func (r *dnsResolver) lookup(ctx context.Context, host string) ([]net.IP, error) {
+	key := strings.ToLower(host)
+	if ips, ok := r.overrides[key]; ok {
+		r.hits.Add(1)
+		return r.sort(ips), nil
+	}
+
+	r.mu.Lock()
+	if e, ok := r.cache[key]; ok {
+		if time.Now().Before(e.expires) {
+			r.mu.Unlock()
+			r.hits.Add(1)
+			return r.sort(e.ips), nil
+		}
+		delete(r.cache, key)
+	}
+	if call, ok := r.inflight[key]; ok {
+		r.mu.Unlock()
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if call.err != nil {
+			return nil, call.err
+		}
+		r.hits.Add(1)
+		return r.sort(call.ips), nil
+	}
+	call := &dnsCall{done: make(chan struct{})}
+	r.inflight[key] = call
+	r.mu.Unlock()
+
+	r.misses.Add(1)
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.DNSStart != nil {
+		trace.DNSStart(httptrace.DNSStartInfo{Host: host})
+	}
+	// 解析结果由所有等待者共享，不受发起者 ctx 取消影响
+	call.ips, call.ttl, call.err = r.resolve(context.WithoutCancel(ctx), host)
+	if trace != nil && trace.DNSDone != nil {
+		addrs := make([]net.IPAddr, len(call.ips))
+		for i, ip := range call.ips {
+			addrs[i] = net.IPAddr{IP: ip}
+		}
+		trace.DNSDone(httptrace.DNSDoneInfo{Addrs: addrs, Err: call.err})
+	}
+
+	r.mu.Lock()
+	delete(r.inflight, key)
+	if call.err == nil && r.ttl > 0 && call.ttl != 0 {
+		ttl := r.ttl
+		if call.ttl > 0 && call.ttl < ttl {
+			ttl = call.ttl
+		}
+		now := time.Now()
+		r.sweepLocked(now)
+		r.cache[key] = dnsEntry{ips: call.ips, expires: now.Add(ttl)}
+	}
+	r.mu.Unlock()
+	close(call.done)
+
+	if call.err != nil {
+		r.errors.Add(1)
+		return nil, call.err
+	}
+	return r.sort(call.ips), nil
+}
+
+// sweepLocked 删除已过期的缓存条目，最多每个 DNSCacheTTL 周期执行一次，调用方须持有 r.mu。
+// 条目有效期不超过 DNSCacheTTL，因此缓存只保留最近约两个周期内解析过的主机。
+func (r *dnsResolver) sweepLocked(now time.Time) {
+	if now.Sub(r.lastSweep) < r.ttl {
+		return
+	}
+	r.lastSweep = now
+	for key, e := range r.cache {
+		if !now.Before(e.expires) {
+			delete(r.cache, key)
+		}
+	}
+}
+
+// resolve 调用底层解析器，返回 IP 及 TTL（未知时为 ttlUnknown）。
+func (r *dnsResolver) resolve(ctx context.Context, host string) ([]net.IP, time.Duration, error) {
+	if tr, ok := r.resolver.(ttlResolver); ok {
+		return tr.lookupIPTTL(ctx, host)
+	}
+	addrs, err := r.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, 0, err
+	}
+	ips := make([]net.IP, 0, len(addrs))
+	for _, a := range addrs {
+		ips = append(ips, a.IP)
+	}
+	return ips, ttlUnknown, nil
+}
+
+// sort 按 IP 版本偏好稳定排序，返回新切片。
+func (r *dnsResolver) sort(ips []net.IP) []net.IP {
+	if r.prefer == "" {
+		return append([]net.IP(nil), ips...)
+	}
+	wantV4 := r.prefer == PreferIPv4
+	out := make([]net.IP, 0, len(ips))
+	var rest []net.IP
+	for _, ip := range ips {
+		if (ip.To4() != nil) == wantV4 {
+			out = append(out, ip)
+		} else {
+			rest = append(rest, ip)
+		}
+	}
+	return append(out, rest...)
+}
+
+// stats 返回缓存统计快照。
+func (r *dnsResolver) stats() DNSStats {
+	r.mu.Lock()
+	entries := len(r.cache)
+	r.mu.Unlock()
+	return DNSStats{
+		Hits:    r.hits.Load(),
+		Misses:  r.misses.Load(),
+		Errors:  r.errors.Load(),
+		Entries: entries,
+	}
+}
+
+// flush 清空 DNS 缓存。
+func (r *dnsResolver) flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cache = make(map[string]dnsEntry)
+}
+
+// DNSStats 返回 DNS 缓存统计；未配置 DNS 选项时返回零值。
+func (h *HttpClient) DNSStats() DNSStats {
+	if h.dns == nil {
+		return DNSStats{}
+	}
+	return h.dns.stats()
+}
+
+// FlushDNSCache 清空 DNS 缓存（静态覆盖不受影响）。
+func (h *HttpClient) FlushDNSCache() {
+	if h.dns != nil {
+		h.dns.flush()
+	}
+}
+
+// dialResolved 先经 lookup 解析主机名，再依次尝试各 IP 直至拨号成功。
+func (h *HttpClient) dialResolved(ctx context.Context, network, addr string, lookup func(context.Context, string) ([]net.IP, error), dial dialFunc) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return dial(ctx, network, addr)
+	}
+	ips, err := lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	var firstErr error
+	for _, ip := range ips {
+		if network == "tcp4" && ip.To4() == nil || network == "tcp6" && ip.To4() != nil {
+			continue
+		}
+		conn, err := dial(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if firstErr == nil {
+		firstErr = &net.DNSError{Err: "no address for network " + network, Name: host, IsNotFound: true}
+	}
+	return nil, firstErr
+}
+
+// lookupSystem 使用系统解析器解析主机名。
+func lookupSystem(ctx context.Context, host string) ([]net.IP, error) {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]net.IP, len(addrs))
+	for i, a := range addrs {
+		ips[i] = a.IP
+	}
+	return ips, nil
+}
+
+// DNSServerResolver 直接向指定 DNS 服务器（UDP，截断时改用 TCP）查询 A/AAAA 记录，
+// 并返回记录 TTL 供缓存使用。
+type DNSServerResolver struct {
+	Addr    string        // 如 "8.8.8.8:53"，省略端口时默认 53
+	Timeout time.Duration // 单次查询超时，默认 5s
+}
+
+// NewDNSServerResolver 创建指向指定 DNS 服务器的解析器。
+func NewDNSServerResolver(addr string) *DNSServerResolver {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "53")
+	}
+	return &DNSServerResolver{Addr: addr, Timeout: 5 * time.Second}
+}
+
+// LookupIPAddr 实现 Resolver 接口。
+func (r *DNSServerResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	ips, _, err := r.lookupIPTTL(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]net.IPAddr, len(ips))
+	for i, ip := range ips {
+		addrs[i] = net.IPAddr{IP: ip}
+	}
+	return addrs, nil
+}
+
+func (r *DNSServerResolver) lookupIPTTL(ctx context.Context, host string) ([]net.IP, time.Duration, error) {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return lookupBoth(ctx, host, r.Addr, func(ctx context.Context, query []byte) ([]byte, error) {
+		return r.exchange(ctx, query)
+	})
+}
+
+// exchange 通过 UDP 发送查询，响应被截断时改用 TCP 重新查询。
+func (r *DNSServerResolver) exchange(ctx context.Context, query []byte) ([]byte, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", r.Addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 4096)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	var p dnsmessage.Parser
+	if h, err := p.Start(buf[:n]); err == nil && h.Truncated {
+		return r.exchangeTCP(ctx, query)
+	}
+	return buf[:n], nil
+}
+
+// exchangeTCP 通过 TCP（2 字节长度前缀）发送查询。
+func (r *DNSServerResolver) exchangeTCP(ctx context.Context, query []byte) ([]byte, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", r.Addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	msg := make([]byte, 2+len(query))
+	binary.BigEndian.PutUint16(msg, uint16(len(query)))
+	copy(msg[2:], query)
+	if _, err := conn.Write(msg); err != nil {
+		return nil, err
+	}
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+	resp := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// dnsExchangeFunc 发送一条 DNS 报文并返回响应报文。
+type dnsExchangeFunc func(ctx context.Context, query []byte) ([]byte, error)
+
+// lookupBoth 并发查询 A 与 AAAA 记录，合并结果并取最小 TTL。
+func lookupBoth(ctx context.Context, host, server string, exchange dnsExchangeFunc) ([]net.IP, time.Duration, error) {
+	type result struct {
+		ips []net.IP
+		ttl time.Duration
+		err error
+	}
+	types := []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA}
+	results := make([]result, len(types))
+	var wg sync.WaitGroup
+	for i, qtype := range types {
+		wg.Add(1)
+		go func(i int, qtype dnsmessage.Type) {
+			defer wg.Done()
+			query, _, err := buildDNSQuery(host, qtype)
+			if err != nil {
+				results[i].err = err
+				return
+			}
+			resp, err := exchange(ctx, query)
+			if err != nil {
+				results[i].err = err
+				return
+			}
+			// exchange 可能改写报文 ID（DoH 置 0），以发出时的 ID 为准
+			id := uint16(query[0])<<8 | uint16(query[1])
+			results[i].ips, results[i].ttl, results[i].err = parseDNSResponse(resp, id)
+		}(i, qtype)
+	}
+	wg.Wait()
+
+	var (
+		ips []net.IP
+		ttl = ttlUnknown
+		err error
+	)
+	for _, res := range results {
+		if res.err != nil {
+			err = res.err
+			continue
+		}
+		ips = append(ips, res.ips...)
+		if res.ttl >= 0 && (ttl < 0 || res.ttl < ttl) {
+			ttl = res.ttl
+		}
+	}
+	if len(ips) > 0 {
+		return ips, ttl, nil
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		if dnsErr.Name == "" {
+			dnsErr.Name, dnsErr.Server = host, server
+		}
+		return nil, 0, err
+	}
+	if err == nil {
+		return nil, 0, &net.DNSError{Err: "no such host", Name: host, Server: server, IsNotFound: true}
+	}
+	return nil, 0, &net.DNSError{Err: err.Error(), Name: host, Server: server, IsTimeout: IsTimeoutError(err)}
+}
+
+// buildDNSQuery 构造一条启用递归的查询报文。
+func buildDNSQuery(host string, qtype dnsmessage.Type) ([]byte, uint16, error) {
+	if !strings.HasSuffix(host, ".") {
+		host += "."
+	}
+	name, err := dnsmessage.NewName(host)
+	if err != nil {
+		return nil, 0, &net.DNSError{Err: "invalid host name", Name: host}
+	}
+	id := uint16(rand.Uint32())
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{
+			Name:  name,
+			Type:  qtype,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+	b, err := msg.Pack()
+	return b, id, err
+}
+
+// parseDNSResponse 解析响应报文中的 A/AAAA 记录及最小 TTL（没有记录时为 ttlUnknown）；id 为 0 时不校验报文 ID。
+func parseDNSResponse(b []byte, id uint16) ([]net.IP, time.Duration, error) {
+	var p dnsmessage.Parser
+	h, err := p.Start(b)
+	if err != nil {
+		return nil, 0, err
+	}
+	if id != 0 && h.ID != id {
+		return nil, 0, errors.New("dns: response id mismatch")
+	}
+	switch h.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, 0, &net.DNSError{Err: "no such host", IsNotFound: true}
+	default:
+		return nil, 0, &net.DNSError{Err: "server misbehaving: " + h.RCode.String(), IsTemporary: true}
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return nil, 0, err
+	}
+	var ips []net.IP
+	ttl := ttlUnknown
+	for {
+		ah, err := p.AnswerHeader()
+		if err == dnsmessage.ErrSectionDone {
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		switch ah.Type {
+		case dnsmessage.TypeA:
+			r, err := p.AResource()
+			if err != nil {
+				return nil, 0, err
+			}
+			ips = append(ips, net.IP(r.A[:]))
+		case dnsmessage.TypeAAAA:
+			r, err := p.AAAAResource()
+			if err != nil {
+				return nil, 0, err
+			}
+			ips = append(ips, net.IP(r.AAAA[:]))
+		default:
+			if err := p.SkipAnswer(); err != nil {
+				return nil, 0, err
+			}
+			continue
+		}
+		if t := time.Duration(ah.TTL) * time.Second; ttl < 0 || t < ttl {
+			ttl = t
+		}
+	}
+	return ips, ttl, nil
+}
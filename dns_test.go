@@ -0,0 +1,257 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// countingResolver 固定返回 127.0.0.1 并统计调用次数。
+type countingResolver struct {
+	calls atomic.Int32
+}
+
+func (r *countingResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	r.calls.Add(1)
+	return []net.IPAddr{{IP: net.ParseIP("127.0.0.1")}}, nil
+}
+
+// startDNSStub 启动一个本地 UDP DNS 服务端：*.test 返回 127.0.0.1（TTL 1s，zero.test 为 0），其余返回 NXDOMAIN。
+func startDNSStub(t *testing.T) string {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen udp failed: %v", err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if resp := dnsStubAnswer(buf[:n]); resp != nil {
+				pc.WriteTo(resp, addr)
+			}
+		}
+	}()
+	return pc.LocalAddr().String()
+}
+
+// dnsStubAnswer 生成 DNS 测试桩的响应报文。
+func dnsStubAnswer(query []byte) []byte {
+	var p dnsmessage.Parser
+	h, err := p.Start(query)
+	if err != nil {
+		return nil
+	}
+	q, err := p.Question()
+	if err != nil {
+		return nil
+	}
+	resp := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: h.ID, Response: true, RecursionAvailable: true},
+		Questions: []dnsmessage.Question{q},
+	}
+	switch {
+	case !strings.HasSuffix(q.Name.String(), ".test."):
+		resp.Header.RCode = dnsmessage.RCodeNameError
+	case q.Type == dnsmessage.TypeA:
+		ttl := uint32(1)
+		if q.Name.String() == "zero.test." {
+			ttl = 0
+		}
+		resp.Answers = []dnsmessage.Resource{{
+			Header: dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: ttl},
+			Body:   &dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}},
+		}}
+	}
+	b, _ := resp.Pack()
+	return b
+}
+
+func TestDNS_ResolveOverride(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	defer ts.Close()
+	_, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+
+	c := NewHttpClientWithTransport("http://api.example.invalid:"+port, &TransportConfig{
+		Resolve: map[string]string{"api.example.invalid": "127.0.0.1"},
+	})
+	body, err := c.DoGet("/")
+	if err != nil {
+		t.Fatalf("DoGet failed: %v", err)
+	}
+	if string(body) != "api.example.invalid:"+port {
+		t.Fatalf("Host header should keep the original name, got %s", body)
+	}
+	if c.DNSStats().Hits == 0 {
+		t.Fatal("override lookups should be counted as hits")
+	}
+}
+
+func TestDNS_ResolveOverride_TLSPaths(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+	_, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+
+	// httptest 证书包含 example.com，覆盖解析后 SNI/证书校验仍使用原始主机名
+	for _, profile := range tlsProfiles {
+		c := NewHttpClientWithTransport("https://example.com:"+port, &TransportConfig{
+			Resolve: map[string]string{"example.com": "127.0.0.1"},
+		})
+		trustServer(t, c, ts, profile)
+		if _, err := c.DoGet("/"); err != nil {
+			t.Fatalf("[%s] DoGet failed: %v", profile, err)
+		}
+	}
+}
+
+func TestDNS_CacheAndFlush(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+	_, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+
+	res := &countingResolver{}
+	c := NewHttpClientWithTransport("http://cached.invalid:"+port, &TransportConfig{
+		Resolver:    res,
+		DNSCacheTTL: time.Minute,
+	})
+	for i := 0; i < 3; i++ {
+		if _, err := c.DoGet("/"); err != nil {
+			t.Fatalf("DoGet failed: %v", err)
+		}
+		c.Close() // 强制新建连接，触发解析
+	}
+	if res.calls.Load() != 1 {
+		t.Fatalf("expected 1 resolver call, got %d", res.calls.Load())
+	}
+	st := c.DNSStats()
+	if st.Misses != 1 || st.Hits != 2 || st.Entries != 1 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+	c.FlushDNSCache()
+	if _, err := c.DoGet("/"); err != nil {
+		t.Fatalf("DoGet after flush failed: %v", err)
+	}
+	if res.calls.Load() != 2 {
+		t.Fatalf("flush should force a new lookup, got %d calls", res.calls.Load())
+	}
+}
+
+func TestDNSServerResolver_TTLAndNXDomain(t *testing.T) {
+	r := NewDNSServerResolver(startDNSStub(t))
+	ips, ttl, err := r.lookupIPTTL(context.Background(), "svc.test")
+	if err != nil {
+		t.Fatalf("lookup failed: %v", err)
+	}
+	if len(ips) != 1 || !ips[0].Equal(net.ParseIP("127.0.0.1")) || ttl != time.Second {
+		t.Fatalf("unexpected answer: %v ttl=%v", ips, ttl)
+	}
+	_, err = r.LookupIPAddr(context.Background(), "missing.example")
+	if !IsDNSError(err) {
+		t.Fatalf("expected DNS error for NXDOMAIN, got %v", err)
+	}
+}
+
+func TestDNS_CacheRespectsRecordTTL(t *testing.T) {
+	dr, err := newDNSResolver(&TransportConfig{DNSServer: startDNSStub(t), DNSCacheTTL: time.Hour})
+	if err != nil {
+		t.Fatalf("newDNSResolver failed: %v", err)
+	}
+	if _, err := dr.lookup(context.Background(), "svc.test"); err != nil {
+		t.Fatalf("lookup failed: %v", err)
+	}
+	if ttl := time.Until(dr.cache["svc.test"].expires); ttl > time.Second {
+		t.Fatalf("cache TTL should follow the 1s record TTL, got %v", ttl)
+	}
+
+	// 记录 TTL 为 0 表示不可缓存
+	for i := 0; i < 2; i++ {
+		if _, err := dr.lookup(context.Background(), "zero.test"); err != nil {
+			t.Fatalf("lookup failed: %v", err)
+		}
+	}
+	if st := dr.stats(); st.Misses != 3 || st.Entries != 1 {
+		t.Fatalf("zero TTL answer should not be cached, got %+v", st)
+	}
+}
+
+func TestDNS_CacheSweepsExpiredEntries(t *testing.T) {
+	dr, err := newDNSResolver(&TransportConfig{Resolver: &countingResolver{}, DNSCacheTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("newDNSResolver failed: %v", err)
+	}
+	past := time.Now().Add(-time.Second)
+	for _, host := range []string{"a.invalid", "b.invalid", "c.invalid"} {
+		dr.cache[host] = dnsEntry{ips: []net.IP{net.ParseIP("127.0.0.1")}, expires: past}
+	}
+	if _, err := dr.lookup(context.Background(), "new.invalid"); err != nil {
+		t.Fatalf("lookup failed: %v", err)
+	}
+	if st := dr.stats(); st.Entries != 1 {
+		t.Fatalf("expired entries should be swept on insert, got %d entries", st.Entries)
+	}
+	// 一个周期内不重复清理
+	dr.cache["d.invalid"] = dnsEntry{expires: past}
+	dr.lookup(context.Background(), "other.invalid")
+	if st := dr.stats(); st.Entries != 3 {
+		t.Fatalf("sweep should run at most once per TTL, got %d entries", st.Entries)
+	}
+}
+
+func TestDNS_PreferIP(t *testing.T) {
+	dr, err := newDNSResolver(&TransportConfig{
+		Resolve:  map[string]string{"dual.test": "::1, 127.0.0.1"},
+		PreferIP: PreferIPv4,
+	})
+	if err != nil {
+		t.Fatalf("newDNSResolver failed: %v", err)
+	}
+	ips, _ := dr.lookup(context.Background(), "DUAL.test")
+	if len(ips) != 2 || ips[0].To4() == nil {
+		t.Fatalf("IPv4 should be preferred, got %v", ips)
+	}
+	if _, err := newDNSResolver(&TransportConfig{PreferIP: "ipx"}); err == nil {
+		t.Fatal("expected error for invalid PreferIP")
+	}
+	if _, err := newDNSResolver(&TransportConfig{Resolve: map[string]string{"a": "not-ip"}}); err == nil {
+		t.Fatal("expected error for invalid override IP")
+	}
+}
+
+func TestNewHttpClientWithTransport_InvalidDNSConfig(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+	_, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+
+	tc := &TransportConfig{
+		PreferIP: "ipx",
+		Resolve:  map[string]string{"api.example.invalid": "not-ip, 127.0.0.1"},
+	}
+	c, err := NewHttpClientWithTransportE("http://api.example.invalid:"+port, tc)
+	if err == nil || !strings.Contains(err.Error(), "PreferIP") || !strings.Contains(err.Error(), "not-ip") {
+		t.Fatalf("expected both config errors, got %v", err)
+	}
+	// 不 panic，无效项被忽略，其余配置照常生效
+	for _, c := range []*HttpClient{c, NewHttpClientWithTransport("http://api.example.invalid:"+port, tc)} {
+		if body, err := c.DoGet("/"); err != nil || string(body) != "ok" {
+			t.Fatalf("valid overrides should still apply: %q, %v", body, err)
+		}
+	}
+}
@@ -7,9 +7,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptrace"
 	"time"
-
-	"go.uber.org/zap"
 )
 
 // doRequest 使用默认 client 执行请求。
@@ -17,44 +16,126 @@ func (h *HttpClient) doRequest(req *http.Request) ([]byte, error) {
 	return h.doRequestWith(req, h.client)
 }
 
-// clientWithSession 使用 Session 的 jar 创建一个临时 http.Client（共享 transport）。
+// clientWithSession 使用 Session 的 jar 创建一个临时 http.Client
+// （共享 transport；启用 JA3 轮换时使用 Session 独占的 transport）。
 func (h *HttpClient) clientWithSession(s *Session) *http.Client {
 	return &http.Client{
-		Transport: h.transport,
-		Timeout:   h.client.Timeout,
-		Jar:       s.jar,
+		Transport:     h.roundTripperFor(h.transportFor(s)),
+		Timeout:       h.client.Timeout,
+		Jar:           s.jar,
+		CheckRedirect: h.checkRedirect,
 	}
 }
 
-// doRequestWith 执行实际 HTTP 请求，包含并发限速、自动解压、错误重试及详细日志。
+// doRequestWith 执行实际 HTTP 请求并只返回响应 body。
 func (h *HttpClient) doRequestWith(req *http.Request, c *http.Client) ([]byte, error) {
+	resp, err := h.send(req, c)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// send 执行实际 HTTP 请求，包含并发限速、指标采集与追踪，具体收发见 execute。
+func (h *HttpClient) send(req *http.Request, c *http.Client) (*Response, error) {
+	req, id := h.assignRequestID(req)
+	m := h.getMetrics()
 	// 并发限速
 	if h.semaphore != nil {
-		h.semaphore <- struct{}{}
+		if m != nil {
+			m.QueueChanged(1)
+			waitStart := time.Now()
+			h.semaphore <- struct{}{}
+			m.QueueChanged(-1)
+			m.QueueWait(time.Since(waitStart))
+		} else {
+			h.semaphore <- struct{}{}
+		}
 		defer func() { <-h.semaphore }()
 	}
 
+	var span Span
+	if t := h.getTracer(); t != nil {
+		req, span = startSpan(t, h.getRedactor(), req, "HTTP "+req.Method)
+	}
+	if m != nil {
+		m.InFlight(1)
+		defer m.InFlight(-1)
+	}
+
+	start := time.Now()
+	resp, err := h.execute(req, c)
+	if err != nil {
+		err = &RequestError{RequestID: id, Err: err}
+	} else {
+		resp.RequestID = id
+	}
+	if span != nil {
+		endSpan(span, resp.status(), err)
+	}
+	if m != nil {
+		m.Observe(RequestMetric{
+			RequestID: id,
+			Host:      req.URL.Host,
+			Method:    req.Method,
+			Status:    resp.status(),
+			Err:       err,
+			Duration:  time.Since(start),
+			BytesOut:  max(req.ContentLength, 0),
+			BytesIn:   resp.bodySize(),
+		})
+	}
+	return resp, err
+}
+
+// execute 发送请求并读取响应，包含自动解压、错误重试及详细日志。
+func (h *HttpClient) execute(req *http.Request, c *http.Client) (*Response, error) {
+	// 日志按 LogPolicy 分级、采样与截断，字段只在事件会输出时构造
+	lg := h.newRequestLog(req)
+
 	// 一次性读取请求体，供日志和重试使用
 	var bodyBytes []byte
 	if req.Body != nil {
 		var err error
 		bodyBytes, err = io.ReadAll(req.Body)
-		if err != nil {
-			h.LogInfo("读取请求体失败", zap.Error(err))
+		if err != nil && lg.enabled(LogEventFailure) {
+			lg.log(LogEventFailure, "读取请求体失败", "error", err)
 		}
 		req.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+		// 供 HTTP/3 回退及重定向重放请求体
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(bodyBytes)), nil
+		}
 	}
-	requestBody := string(bodyBytes)
 
 	// 用 Set 避免重试时 Accept-Encoding 重复追加
 	req.Header.Set("Accept-Encoding", "gzip")
 
-	h.LogInfo("请求准备发送",
-		"method", req.Method,
-		"url", req.URL.String(),
-		"headers", req.Header,
-		"body", requestBody,
-	)
+	// 日志中的 URL、请求头与 body 均先经脱敏；未设置 logger 时跳过
+	rd := lg.rd
+	var logURL, requestBody string
+	if h.logger != nil {
+		logURL = rd.url(req.URL.String())
+		requestBody = lg.body(bodyBytes, req.Header.Get("Content-Type"))
+	}
+
+	if lg.enabled(LogEventSend) {
+		lg.log(LogEventSend, "请求准备发送",
+			"method", req.Method,
+			"url", logURL,
+			"headers", rd.header(req.Header),
+			"body", requestBody,
+		)
+	}
+
+	// 记录实际使用的连接信息（重试时覆盖为最后一次的连接）
+	conn := &ConnInfo{}
+	timing := newTimingTrace()
+	req = req.WithContext(timing.withContext(httptrace.WithClientTrace(req.Context(), connInfoTrace(conn))))
+
+	// 调用方显式设置了 traceparent 时不覆盖
+	tracer := h.getTracer()
+	injectTrace := req.Header.Get("traceparent") == ""
 
 	const maxRetries = 3
 	var (
@@ -67,16 +148,37 @@ func (h *HttpClient) doRequestWith(req *http.Request, c *http.Client) ([]byte, e
 			if len(bodyBytes) > 0 {
 				req.Body = io.NopCloser(bytes.NewReader(bodyBytes))
 			}
+			if m := h.getMetrics(); m != nil {
+				m.Retry(req.URL.Host, req.Method)
+			}
 			wait := time.Duration(attempt) * 200 * time.Millisecond
-			h.LogInfo("请求重试",
-				"attempt", attempt,
-				"wait", wait.String(),
-				"url", req.URL.String(),
-			)
+			if lg.enabled(LogEventRetry) {
+				lg.log(LogEventRetry, "请求重试",
+					"attempt", attempt,
+					"wait", wait.String(),
+					"url", logURL,
+				)
+			}
 			time.Sleep(wait)
 		}
+		timing.setAttempt(attempt + 1)
 
-		res, err = c.Do(req)
+		attemptReq, span := req, Span(nil)
+		if tracer != nil {
+			attemptReq, span = startSpan(tracer, rd, req, "HTTP "+req.Method+" attempt")
+			span.SetAttributes(AttrResendCount, attempt)
+		}
+		if injectTrace {
+			injectTraceContext(attemptReq, span)
+		}
+		res, err = c.Do(attemptReq)
+		if span != nil {
+			status := 0
+			if res != nil {
+				status = res.StatusCode
+			}
+			endSpan(span, status, err)
+		}
 		if err == nil {
 			break
 		}
@@ -87,13 +189,19 @@ func (h *HttpClient) doRequestWith(req *http.Request, c *http.Client) ([]byte, e
 	}
 
 	if err != nil {
-		h.LogInfo("请求失败",
-			"error", err,
-			"method", req.Method,
-			"url", req.URL.String(),
-			"headers", req.Header,
-			"body", requestBody,
-		)
+		if lg.enabled(LogEventFailure) {
+			fields := []interface{}{
+				"error", rd.error(err),
+				"method", req.Method,
+				"url", logURL,
+				"headers", rd.header(req.Header),
+				"body", requestBody,
+			}
+			if h.curlLogging() {
+				fields = append(fields, "curl", h.curlForLog(req, bodyBytes, c.Jar, lg))
+			}
+			lg.log(LogEventFailure, "请求失败", fields...)
+		}
 		switch {
 		case IsTimeoutError(err):
 			return nil, errors.New("请求超时")
@@ -115,7 +223,9 @@ func (h *HttpClient) doRequestWith(req *http.Request, c *http.Client) ([]byte, e
 	if res.Header.Get("Content-Encoding") == "gzip" {
 		gzReader, err := gzip.NewReader(res.Body)
 		if err != nil {
-			h.LogInfo("解压 gzip 失败", zap.Error(err))
+			if lg.enabled(LogEventFailure) {
+				lg.log(LogEventFailure, "解压 gzip 失败", "error", err, "url", logURL)
+			}
 			return nil, err
 		}
 		defer gzReader.Close()
@@ -124,37 +234,78 @@ func (h *HttpClient) doRequestWith(req *http.Request, c *http.Client) ([]byte, e
 
 	body, err := io.ReadAll(reader)
 	if err != nil {
-		h.LogInfo("读取响应失败",
-			zap.Error(err),
-			"method", req.Method,
-			"url", req.URL.String(),
-		)
+		if lg.enabled(LogEventFailure) {
+			lg.log(LogEventFailure, "读取响应失败",
+				"error", err,
+				"method", req.Method,
+				"url", logURL,
+			)
+		}
 		return nil, err
 	}
 
+	t := timing.finish()
+	conn.Proto = res.Proto
+	if !conn.TLS && res.TLS != nil {
+		// HTTP/3 的 QUIC 连接不是 net.Conn，TLS 信息取自响应
+		conn.setTLSState(res.TLS)
+	}
+	resp := newResponse(res, body, conn)
+	resp.Timing = t
+	ev, msg := LogEventSuccess, "请求成功"
 	if res.StatusCode < 200 || res.StatusCode >= 300 {
-		h.LogInfo("请求返回非成功状态",
+		ev, msg = LogEventStatus, "请求返回非成功状态"
+	}
+	if lg.enabled(ev) {
+		fields := []interface{}{
 			"status", res.StatusCode,
 			"method", req.Method,
-			"url", req.URL.String(),
-			"request_headers", req.Header,
+			"url", logURL,
+			"request_headers", rd.header(req.Header),
 			"request_body", requestBody,
-			"response_headers", fmt.Sprintf("%v", res.Header),
-			"response_body", string(body),
-		)
-		return body, nil
+			"response_headers", fmt.Sprintf("%v", rd.header(res.Header)),
+			"response_body", lg.body(body, res.Header.Get("Content-Type")),
+		}
+		h.mu.RLock()
+		if h.logConnInfo {
+			fields = append(fields, conn.logFields()...)
+		}
+		if h.logTiming {
+			fields = append(fields, t.logFields()...)
+		}
+		logCurl := h.logCurl
+		h.mu.RUnlock()
+		if logCurl {
+			fields = append(fields, "curl", h.curlForLog(req, bodyBytes, c.Jar, lg))
+		}
+		lg.log(ev, msg, fields...)
 	}
 
-	h.LogInfo("请求成功",
-		"status", res.StatusCode,
-		"method", req.Method,
-		"url", req.URL.String(),
-		"request_headers", req.Header,
-		"request_body", requestBody,
-		"response_headers", fmt.Sprintf("%v", res.Header),
-		"response_body", string(body),
-	)
+	return resp, nil
+}
 
-	return body, nil
+// status 返回状态码，resp 为 nil 时返回 0。
+func (r *Response) status() int {
+	if r == nil {
+		return 0
+	}
+	return r.StatusCode
 }
 
+// bodySize 返回响应体字节数，resp 为 nil 时返回 0。
+func (r *Response) bodySize() int64 {
+	if r == nil {
+		return 0
+	}
+	return int64(len(r.Body))
+}
+
+// newResponse 基于已读取完毕的 http.Response 构建 Response。
+func newResponse(res *http.Response, body []byte, conn *ConnInfo) *Response {
+	return &Response{
+		StatusCode: res.StatusCode,
+		Header:     res.Header.Clone(),
+		Body:       body,
+		Conn:       conn,
+	}
+}
@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DoHResolver DNS-over-HTTPS 解析器，支持 RFC 8484 wire 格式（POST application/dns-message）
+// 与 JSON API（GET application/dns-json），查询本身通过本库的 HttpClient 发送。
+// 作为 TransportConfig.Resolver 使用时，查询结果的 TTL 会被 DNS 缓存采用。
+type DoHResolver struct {
+	URL    string      // 如 "https://1.1.1.1/dns-query"
+	JSON   bool        // true 使用 JSON API
+	Client *HttpClient // 发送查询使用的客户端，可自行配置代理/TLS；不要让它再使用本解析器
+}
+
+// NewDoHResolver 创建 DoH 解析器；useJSON=true 时使用 JSON API，默认 RFC 8484 wire 格式。
+func NewDoHResolver(endpoint string, useJSON ...bool) *DoHResolver {
+	return &DoHResolver{
+		URL:    endpoint,
+		JSON:   len(useJSON) > 0 && useJSON[0],
+		Client: NewHttpClient(endpoint, 5*time.Second),
+	}
+}
+
+// LookupIPAddr 实现 Resolver 接口。
+func (r *DoHResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	ips, _, err := r.lookupIPTTL(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]net.IPAddr, len(ips))
+	for i, ip := range ips {
+		addrs[i] = net.IPAddr{IP: ip}
+	}
+	return addrs, nil
+}
+
+func (r *DoHResolver) lookupIPTTL(ctx context.Context, host string) ([]net.IP, time.Duration, error) {
+	if r.JSON {
+		return r.lookupJSON(ctx, host)
+	}
+	return lookupBoth(ctx, host, r.URL, r.exchange)
+}
+
+// withoutClientTrace 屏蔽父 context 中的 httptrace 钩子，其余 value（关联 ID、追踪 span 等）与取消照常继承。
+// DoH 查询发生在外层请求拨号期间，需避免外层请求的钩子被查询请求触发；
+// httptrace.WithClientTrace 会与已有钩子组合，无法用空 ClientTrace 覆盖，只能按 key 屏蔽。
+type withoutClientTrace struct{ context.Context }
+
+func (c withoutClientTrace) Value(key any) any {
+	if key == clientTraceKey {
+		return nil
+	}
+	return c.Context.Value(key)
+}
+
+// clientTraceKey httptrace 存放 ClientTrace 的 context key（未导出，通过探测取得）。
+var clientTraceKey = func() any {
+	var p keyProbe
+	httptrace.ContextClientTrace(&p)
+	return p.key
+}()
+
+// keyProbe 记录被查询的 context key。
+type keyProbe struct {
+	context.Context
+	key any
+}
+
+func (p *keyProbe) Value(key any) any {
+	p.key = key
+	return nil
+}
+
+// exchange 以 RFC 8484 POST 方式发送 wire 格式查询，报文 ID 置 0 以便 HTTP 缓存。
+func (r *DoHResolver) exchange(ctx context.Context, query []byte) ([]byte, error) {
+	query[0], query[1] = 0, 0
+	resp, err := r.Client.Do(&Request{
+		Context: withoutClientTrace{ctx},
+		Method:  "POST",
+		Path:    r.URL,
+		Header: http.Header{
+			"Content-Type": {"application/dns-message"},
+			"Accept":       {"application/dns-message"},
+		},
+		Body: query,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("doh: unexpected status %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// dohJSONResponse JSON API 响应（Google / Cloudflare 兼容格式）。
+type dohJSONResponse struct {
+	Status int `json:"Status"`
+	Answer []struct {
+		Type int    `json:"type"`
+		TTL  uint32 `json:"TTL"`
+		Data string `json:"data"`
+	} `json:"Answer"`
+}
+
+// lookupJSON 通过 JSON API 并发查询 A 与 AAAA 记录。
+func (r *DoHResolver) lookupJSON(ctx context.Context, host string) ([]net.IP, time.Duration, error) {
+	types := []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA}
+	answers := make([]*dohJSONResponse, len(types))
+	errs := make([]error, len(types))
+	var wg sync.WaitGroup
+	for i, qtype := range types {
+		wg.Add(1)
+		go func(i int, qtype dnsmessage.Type) {
+			defer wg.Done()
+			answers[i], errs[i] = r.queryJSON(ctx, host, qtype)
+		}(i, qtype)
+	}
+	wg.Wait()
+
+	var (
+		ips     []net.IP
+		ttl     = ttlUnknown
+		lastErr error
+		status  int
+	)
+	for i, ans := range answers {
+		if errs[i] != nil {
+			lastErr = errs[i]
+			continue
+		}
+		if ans.Status != 0 {
+			status = ans.Status
+			continue
+		}
+		for _, a := range ans.Answer {
+			if a.Type != int(dnsmessage.TypeA) && a.Type != int(dnsmessage.TypeAAAA) {
+				continue
+			}
+			ip := net.ParseIP(a.Data)
+			if ip == nil {
+				continue
+			}
+			ips = append(ips, ip)
+			if t := time.Duration(a.TTL) * time.Second; ttl < 0 || t < ttl {
+				ttl = t
+			}
+		}
+	}
+	switch {
+	case len(ips) > 0:
+		return ips, ttl, nil
+	case lastErr != nil:
+		return nil, 0, &net.DNSError{Err: lastErr.Error(), Name: host, Server: r.URL, IsTimeout: IsTimeoutError(lastErr)}
+	case status != 0 && status != int(dnsmessage.RCodeNameError):
+		return nil, 0, &net.DNSError{Err: fmt.Sprintf("server misbehaving: status %d", status), Name: host, Server: r.URL, IsTemporary: true}
+	default:
+		return nil, 0, &net.DNSError{Err: "no such host", Name: host, Server: r.URL, IsNotFound: true}
+	}
+}
+
+// queryJSON 发送单个类型的 JSON API 查询。
+func (r *DoHResolver) queryJSON(ctx context.Context, host string, qtype dnsmessage.Type) (*dohJSONResponse, error) {
+	sep := "?"
+	if strings.Contains(r.URL, "?") {
+		sep = "&"
+	}
+	q := url.Values{"name": {host}, "type": {strings.TrimPrefix(qtype.String(), "Type")}}
+	resp, err := r.Client.Do(&Request{
+		Context: withoutClientTrace{ctx},
+		Method:  "GET",
+		Path:    r.URL + sep + q.Encode(),
+		Header:  http.Header{"Accept": {"application/dns-json"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("doh: unexpected status %d", resp.StatusCode)
+	}
+	var out dohJSONResponse
+	if err := json.Unmarshal(resp.Body, &out); err != nil {
+		return nil, fmt.Errorf("doh: invalid JSON response: %w", err)
+	}
+	return &out, nil
+}
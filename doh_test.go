@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startDoHStub 启动一个 DoH 测试服务端，wire 与 JSON 两种格式都复用 dnsStubAnswer 的应答规则。
+func startDoHStub(t *testing.T) *httptest.Server {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if r.Header.Get("Content-Type") != "application/dns-message" {
+				http.Error(w, "bad content type", http.StatusUnsupportedMediaType)
+				return
+			}
+			query, _ := io.ReadAll(r.Body)
+			if len(query) < 2 || query[0] != 0 || query[1] != 0 {
+				http.Error(w, "DoH queries should use ID 0", http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "application/dns-message")
+			w.Write(dnsStubAnswer(query))
+			return
+		}
+		name, qtype := r.URL.Query().Get("name"), r.URL.Query().Get("type")
+		resp := map[string]any{"Status": 0}
+		switch {
+		case !strings.HasSuffix(name, ".test"):
+			resp["Status"] = 3
+		case qtype == "A":
+			resp["Answer"] = []map[string]any{{"name": name, "type": 1, "TTL": 30, "data": "127.0.0.1"}}
+		}
+		w.Header().Set("Content-Type", "application/dns-json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// newTestDoHResolver 创建信任测试服务端证书的 DoH 解析器。
+func newTestDoHResolver(ts *httptest.Server, useJSON bool) *DoHResolver {
+	r := NewDoHResolver(ts.URL+"/dns-query", useJSON)
+	_ = r.Client.SetTLSConfig(&TLSConfig{RootCAPEM: serverCAPEM(ts)})
+	return r
+}
+
+func TestDoHResolver_Formats(t *testing.T) {
+	ts := startDoHStub(t)
+	cases := []struct {
+		name    string
+		useJSON bool
+		ttl     time.Duration
+	}{
+		{"wire", false, time.Second},
+		{"json", true, 30 * time.Second},
+	}
+	for _, tc := range cases {
+		r := newTestDoHResolver(ts, tc.useJSON)
+		ips, ttl, err := r.lookupIPTTL(context.Background(), "svc.test")
+		if err != nil {
+			t.Fatalf("[%s] lookup failed: %v", tc.name, err)
+		}
+		if len(ips) != 1 || !ips[0].Equal(net.ParseIP("127.0.0.1")) || ttl != tc.ttl {
+			t.Fatalf("[%s] unexpected answer: %v ttl=%v", tc.name, ips, ttl)
+		}
+		_, err = r.LookupIPAddr(context.Background(), "missing.example")
+		if !IsDNSError(err) {
+			t.Fatalf("[%s] expected DNS error for NXDOMAIN, got %v", tc.name, err)
+		}
+	}
+}
+
+func TestDoHResolver_AsTransportResolver(t *testing.T) {
+	doh := startDoHStub(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("via doh"))
+	}))
+	defer ts.Close()
+	_, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+
+	resolver := newTestDoHResolver(doh, false)
+	rec := NewHARRecorder()
+	resolver.Client.SetHARRecorder(rec)
+	c := NewHttpClientWithTransport("http://api.test:"+port, &TransportConfig{
+		Resolver:    resolver,
+		DNSCacheTTL: time.Minute,
+	})
+	ctx := ContextWithRequestID(context.Background(), "outer-1")
+	resp, err := c.Do(&Request{Context: ctx, Path: "/"})
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if string(resp.Body) != "via doh" {
+		t.Fatalf("unexpected body: %s", resp.Body)
+	}
+	// DoH 查询的连接不应混入外层请求的连接信息
+	if resp.Conn.RemoteAddr != ts.Listener.Addr().String() {
+		t.Fatalf("conn info should describe the target, got %s", resp.Conn.RemoteAddr)
+	}
+	if st := c.DNSStats(); st.Misses != 1 || st.Entries != 1 {
+		t.Fatalf("DoH answer should be cached, got %+v", st)
+	}
+	// 查询沿用外层请求的关联 ID
+	for _, e := range rec.Entries() {
+		if e.RequestID != "outer-1" {
+			t.Fatalf("DoH query should inherit the request ID, got %q", e.RequestID)
+		}
+	}
+	if len(rec.Entries()) == 0 {
+		t.Fatal("DoH queries not recorded")
+	}
+}
+
+func TestWithoutClientTrace(t *testing.T) {
+	ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{GotConn: func(httptrace.GotConnInfo) {}})
+	ctx = ContextWithRequestID(ctx, "outer-1")
+	inner := withoutClientTrace{ctx}
+	if httptrace.ContextClientTrace(inner) != nil {
+		t.Fatal("outer httptrace hooks should be hidden")
+	}
+	if RequestIDFromContext(inner) != "outer-1" {
+		t.Fatal("other context values should be kept")
+	}
+}
@@ -15,7 +15,7 @@ import (
 func (h *HttpClient) UploadFile(path, fieldName, filePath string, extraParams map[string]string) ([]byte, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
-		h.LogInfo(fmt.Sprintf("failed to open file: %s", filePath))
+		h.LogError("failed to open file", err, "path", filePath)
 		return nil, fmt.Errorf("打开文件失败: %w", err)
 	}
 	defer file.Close()
@@ -38,23 +38,21 @@ func (h *HttpClient) UploadFile(path, fieldName, filePath string, extraParams ma
 	if err != nil {
 		return nil, fmt.Errorf("创建请求失败: %w", err)
 	}
-	for k, v := range h.GetHeader() {
-		req.Header.Set(k, v)
-	}
+	h.applyHeaders(req, nil)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	return h.doRequest(req)
 }
 
 // DownloadFile 下载远程文件并保存到本地 savePath。
 func (h *HttpClient) DownloadFile(path, savePath string) error {
-	h.LogInfo("DownloadFile called", "url", path, "savePath", savePath)
+	h.LogInfo("DownloadFile called", "url", h.getRedactor().url(path), "savePath", savePath)
 	req, err := http.NewRequest("GET", h.buildFullURL(path), nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
-	for k, v := range h.GetHeader() {
-		req.Header.Set(k, v)
-	}
+	h.applyHeaders(req, nil)
+	// 文件内容不进入 HAR，只记录大小
+	req = req.WithContext(withoutHARContent(req.Context()))
 	resp, err := h.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("download request failed: %w", err)
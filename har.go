@@ -0,0 +1,488 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HAR 记录。
+//
+// SetHARRecorder 开启后在最外层 RoundTripper 记录，client 与 Session 的每次实际发送——包括重试的每次尝试
+// 与自动跟随的每一跳重定向——各记录为一条 HAR 1.2 条目，可写成 .har 文件导入 Chrome DevTools。
+// URL、请求头、cookie 与文本 body 经与日志相同的脱敏规则处理（见 SetRedaction）。
+
+// HAR HTTP Archive 1.2 文件。
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog HAR 的 log 对象。
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator 生成 HAR 的工具。
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry 一次请求/响应。
+type HAREntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"` // 总耗时（毫秒），等于 Timings 中非 -1 项之和
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	RequestID       string      `json:"_requestId,omitempty"` // 请求关联 ID
+}
+
+// HARRequest 请求。
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+// HARResponse 响应；请求失败时 Status 为 0，Error 为错误信息。
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+	Error       string         `json:"_error,omitempty"`
+}
+
+// HARNameValue 请求头、查询参数与表单字段。
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARCookie cookie。
+type HARCookie struct {
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Path     string    `json:"path,omitempty"`
+	Domain   string    `json:"domain,omitempty"`
+	Expires  time.Time `json:"expires,omitzero"`
+	HTTPOnly bool      `json:"httpOnly,omitempty"`
+	Secure   bool      `json:"secure,omitempty"`
+}
+
+// HARPostData 请求体；二进制 body 不记录 Text。
+type HARPostData struct {
+	MimeType string         `json:"mimeType"`
+	Params   []HARNameValue `json:"params,omitempty"`
+	Text     string         `json:"text"`
+	Comment  string         `json:"comment,omitempty"`
+}
+
+// HARContent 响应体（已解压）；二进制内容以 base64 记录，DownloadFile 的内容不记录。
+type HARContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// HARTimings 各阶段耗时（毫秒），未发生的阶段为 -1；Connect 包含 SSL。
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// HARRecorder 收集 HAR 条目，可被多个 client 共享，并发安全。
+type HARRecorder struct {
+	mu      sync.Mutex
+	entries []HAREntry
+}
+
+// NewHARRecorder 创建空的 HARRecorder。
+func NewHARRecorder() *HARRecorder {
+	return &HARRecorder{}
+}
+
+// Entries 返回已记录条目的副本，按开始时间排序。
+func (r *HARRecorder) Entries() []HAREntry {
+	r.mu.Lock()
+	entries := append([]HAREntry(nil), r.entries...)
+	r.mu.Unlock()
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedDateTime.Before(entries[j].StartedDateTime)
+	})
+	return entries
+}
+
+// Reset 清空已记录条目。
+func (r *HARRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+}
+
+// HAR 返回当前记录的 HAR 文档。
+func (r *HARRecorder) HAR() *HAR {
+	entries := r.Entries()
+	if entries == nil {
+		entries = []HAREntry{}
+	}
+	return &HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "github.com/szwtdl/req", Version: "1"},
+		Entries: entries,
+	}}
+}
+
+// WriteTo 以 JSON 写出 HAR 文档。
+func (r *HARRecorder) WriteTo(w io.Writer) (int64, error) {
+	b, err := json.MarshalIndent(r.HAR(), "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(append(b, '\n'))
+	return int64(n), err
+}
+
+// WriteFile 将 HAR 文档写入 path。
+func (r *HARRecorder) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := r.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (r *HARRecorder) add(e HAREntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, e)
+}
+
+// SetHARRecorder 设置 HAR 记录器，nil 表示关闭记录。
+func (h *HttpClient) SetHARRecorder(rec *HARRecorder) {
+	h.mu.Lock()
+	h.har = rec
+	h.mu.Unlock()
+	h.client.Transport = h.roundTripperFor(h.transport)
+}
+
+// harNoContentKey 标记响应内容不进入 HAR（如 DownloadFile 写入文件的内容），只记录大小。
+type harNoContentKey struct{}
+
+// withoutHARContent 返回标记了不记录响应内容的 ctx。
+func withoutHARContent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, harNoContentKey{}, true)
+}
+
+// harTransport 记录经过的每次往返，位于 cassette 与 SetRoundTripper 传输之外。
+// 条目在响应体读完或关闭时生成，未收到响应时立即生成。
+type harTransport struct {
+	h    *HttpClient
+	rec  *HARRecorder
+	next http.RoundTripper
+}
+
+func (t *harTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	timing, conn := newTimingTrace(), &ConnInfo{}
+	out := req.WithContext(timing.withContext(httptrace.WithClientTrace(req.Context(), connInfoTrace(conn))))
+	x := &harExchange{rec: t.rec, start: time.Now(), req: out, timing: timing, conn: conn}
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		x.reqBody = body
+		out.Body = io.NopCloser(bytes.NewReader(body))
+		out.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	res, err := t.next.RoundTrip(out)
+	rd := t.h.getRedactor()
+	if err != nil {
+		x.record(rd, nil, nil, 0, err)
+		return nil, err
+	}
+	res.Body = &harBody{
+		ReadCloser: res.Body,
+		x:          x,
+		rd:         rd,
+		res:        res,
+		keep:       req.Context().Value(harNoContentKey{}) == nil,
+	}
+	return res, nil
+}
+
+// harBody 在读取响应体的同时保留内容，读到结尾、出错或关闭时生成条目（只生成一次）。
+type harBody struct {
+	io.ReadCloser
+	x    *harExchange
+	rd   *redactor
+	res  *http.Response
+	keep bool
+	buf  bytes.Buffer
+	n    int64
+	once sync.Once
+}
+
+func (b *harBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if b.keep {
+		b.buf.Write(p[:n])
+	}
+	switch {
+	case err == io.EOF:
+		b.finish(nil)
+	case err != nil:
+		b.finish(err)
+	}
+	return n, err
+}
+
+func (b *harBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.finish(nil)
+	return err
+}
+
+func (b *harBody) finish(err error) {
+	b.once.Do(func() {
+		if !b.keep {
+			b.x.record(b.rd, b.res, nil, b.n, err)
+			return
+		}
+		body := b.buf.Bytes()
+		if strings.EqualFold(b.res.Header.Get("Content-Encoding"), "gzip") {
+			// 记录解压后的内容，解压失败时保留原始字节
+			if zr, zerr := gzip.NewReader(bytes.NewReader(body)); zerr == nil {
+				if plain, zerr := io.ReadAll(zr); zerr == nil {
+					body = plain
+				}
+			}
+		}
+		b.x.record(b.rd, b.res, body, 0, err)
+	})
+}
+
+// harExchange 一次往返的请求信息与耗时采集。
+type harExchange struct {
+	rec     *HARRecorder
+	start   time.Time
+	req     *http.Request
+	reqBody []byte
+	timing  *timingTrace
+	conn    *ConnInfo
+}
+
+// record 生成条目并追加到 recorder。res 为 nil 表示未收到响应，err 非 nil 时记入 _error；
+// body 为 nil 且 size > 0 表示内容未保留（如已写入文件）。
+func (x *harExchange) record(rd *redactor, res *http.Response, body []byte, size int64, err error) {
+	t := x.timing.finish()
+	req := x.req
+	e := HAREntry{
+		StartedDateTime: x.start,
+		Request:         harRequest(rd, req, x.reqBody),
+		Timings:         harTimings(t),
+		RequestID:       RequestIDFromContext(req.Context()),
+	}
+	e.Time = e.Timings.Blocked + e.Timings.Send + e.Timings.Wait + e.Timings.Receive +
+		max(e.Timings.DNS, 0) + max(e.Timings.Connect, 0)
+	if x.conn != nil && x.conn.RemoteAddr != "" {
+		if host, _, err := net.SplitHostPort(x.conn.RemoteAddr); err == nil {
+			e.ServerIPAddress = host
+		}
+	}
+	if res == nil {
+		e.Request.HTTPVersion = "HTTP/1.1"
+		e.Response = HARResponse{
+			Cookies:     []HARCookie{},
+			Headers:     []HARNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		}
+	} else {
+		e.Request.HTTPVersion = res.Proto
+		e.Response = harResponse(rd, res, body, size)
+	}
+	if err != nil {
+		e.Response.Error = rd.error(err).Error()
+	}
+	x.rec.add(e)
+}
+
+// harRequest 转换请求；RoundTripper 层的请求头已包含 CookieJar 追加的 cookie。
+func harRequest(rd *redactor, req *http.Request, body []byte) HARRequest {
+	header := req.Header.Clone()
+	stripWireLayout(header)
+	cookies := req.Cookies()
+
+	rawURL := rd.url(req.URL.String())
+	r := HARRequest{
+		Method:      req.Method,
+		URL:         rawURL,
+		Cookies:     harCookies(rd, "Cookie", cookies),
+		Headers:     harHeaders(rd.header(header)),
+		QueryString: []HARNameValue{},
+		HeadersSize: -1,
+		BodySize:    int64(len(body)),
+	}
+	if u, err := url.Parse(rawURL); err == nil {
+		for k, vs := range u.Query() {
+			for _, v := range vs {
+				r.QueryString = append(r.QueryString, HARNameValue{Name: k, Value: v})
+			}
+		}
+		sort.SliceStable(r.QueryString, func(i, j int) bool { return r.QueryString[i].Name < r.QueryString[j].Name })
+	}
+	if len(body) > 0 {
+		ct := req.Header.Get("Content-Type")
+		pd := &HARPostData{MimeType: ct}
+		if isTextBody(body, ct) {
+			pd.Text = rd.body(string(body))
+			if mt, _, _ := mime.ParseMediaType(ct); mt == "application/x-www-form-urlencoded" {
+				if vals, err := url.ParseQuery(pd.Text); err == nil {
+					for k, vs := range vals {
+						for _, v := range vs {
+							pd.Params = append(pd.Params, HARNameValue{Name: k, Value: v})
+						}
+					}
+					sort.SliceStable(pd.Params, func(i, j int) bool { return pd.Params[i].Name < pd.Params[j].Name })
+				}
+			}
+		} else {
+			pd.Comment = "binary body omitted, " + strconv.Itoa(len(body)) + " bytes"
+		}
+		r.PostData = pd
+	}
+	return r
+}
+
+func harResponse(rd *redactor, res *http.Response, body []byte, size int64) HARResponse {
+	ct := res.Header.Get("Content-Type")
+	r := HARResponse{
+		Status:      res.StatusCode,
+		StatusText:  strings.TrimPrefix(res.Status, strconv.Itoa(res.StatusCode)+" "),
+		HTTPVersion: res.Proto,
+		Cookies:     harCookies(rd, "Set-Cookie", res.Cookies()),
+		Headers:     harHeaders(rd.header(res.Header)),
+		Content:     HARContent{Size: int64(len(body)), MimeType: ct},
+		HeadersSize: -1,
+		BodySize:    res.ContentLength,
+	}
+	if loc, err := res.Location(); err == nil {
+		r.RedirectURL = rd.url(loc.String())
+	}
+	if res.Request != nil && res.Request.Method == http.MethodHead {
+		r.BodySize = 0
+	}
+	switch {
+	case body == nil && size > 0:
+		r.Content.Size = size
+		r.Content.Comment = "content not recorded"
+	case len(body) == 0:
+	case isTextBody(body, ct):
+		r.Content.Text = rd.body(string(body))
+	default:
+		r.Content.Text = base64.StdEncoding.EncodeToString(body)
+		r.Content.Encoding = "base64"
+	}
+	return r
+}
+
+// harHeaders 将请求头展开为按名称排序的列表。
+func harHeaders(header http.Header) []HARNameValue {
+	out := make([]HARNameValue, 0, len(header))
+	for k, vs := range header {
+		for _, v := range vs {
+			out = append(out, HARNameValue{Name: k, Value: v})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// harCookies 转换 cookie，headerName 对应的请求头需要脱敏时同时隐藏 cookie 值。
+func harCookies(rd *redactor, headerName string, cookies []*http.Cookie) []HARCookie {
+	out := make([]HARCookie, 0, len(cookies))
+	for _, c := range cookies {
+		v := c.Value
+		if rd.headers[headerName] {
+			v = rd.replacement
+		}
+		out = append(out, HARCookie{
+			Name:     c.Name,
+			Value:    v,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			Expires:  c.Expires,
+			HTTPOnly: c.HttpOnly,
+			Secure:   c.Secure,
+		})
+	}
+	return out
+}
+
+// harTimings 将 Timing 换算为 HAR 的阶段耗时，blocked 补齐到总耗时。
+func harTimings(t *Timing) HARTimings {
+	ms := func(d time.Duration) float64 { return float64(d.Microseconds()) / 1000 }
+	opt := func(d time.Duration) float64 {
+		if d <= 0 {
+			return -1
+		}
+		return ms(d)
+	}
+	ht := HARTimings{
+		DNS:     opt(t.DNS),
+		Connect: opt(t.Connect + t.ProxyConnect + t.TLSHandshake),
+		SSL:     opt(t.TLSHandshake),
+		Receive: ms(t.BodyTransfer),
+	}
+	// TTFB 从获取连接开始计时，包含 DNS、建连与握手
+	ht.Wait = max(ms(t.TTFB)-max(ht.DNS, 0)-max(ht.Connect, 0), 0)
+	ht.Blocked = max(ms(t.Total)-max(ht.DNS, 0)-max(ht.Connect, 0)-ht.Wait-ht.Receive, 0)
+	return ht
+}
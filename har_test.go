@@ -0,0 +1,246 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func harHeader(list []HARNameValue, name string) string {
+	for _, nv := range list {
+		if strings.EqualFold(nv.Name, name) {
+			return nv.Value
+		}
+	}
+	return ""
+}
+
+func TestHARRecorder_LoginFlow(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: "s3cret", Path: "/", HttpOnly: true})
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"ok":true,"token":"abc"}`))
+		case "/img":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte{0x89, 'P', 'N', 'G', 0})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	rec := NewHARRecorder()
+	c := NewHttpClient(ts.URL)
+	c.SetHARRecorder(rec)
+	c.SetHeader(map[string]string{"Authorization": "Bearer t0k"})
+	if _, err := c.DoPost("/login", map[string]string{"user": "tom", "password": "hunter2"}); err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	c.DoGet("/me?token=xyz&page=1")
+	c.DoGet("/img")
+
+	entries := rec.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+
+	login := entries[0]
+	if login.Request.Method != "POST" || login.Response.Status != 200 || login.Response.StatusText != "OK" {
+		t.Fatalf("unexpected login entry: %+v", login)
+	}
+	if login.RequestID == "" || login.Request.HTTPVersion != "HTTP/1.1" || login.ServerIPAddress != "127.0.0.1" {
+		t.Fatalf("missing metadata: id=%q version=%q ip=%q", login.RequestID, login.Request.HTTPVersion, login.ServerIPAddress)
+	}
+	pd := login.Request.PostData
+	if pd == nil || strings.Contains(pd.Text, "hunter2") || !strings.Contains(pd.Text, "user=tom") {
+		t.Fatalf("post data not recorded/redacted: %+v", pd)
+	}
+	if harHeader(pd.Params, "password") != DefaultRedactReplacement {
+		t.Fatalf("form params not redacted: %+v", pd.Params)
+	}
+	if harHeader(login.Request.Headers, "Authorization") != DefaultRedactReplacement {
+		t.Fatalf("authorization not redacted: %v", login.Request.Headers)
+	}
+	if got := login.Response.Content.Text; !strings.Contains(got, `"ok":true`) || strings.Contains(got, "abc") {
+		t.Fatalf("response body not recorded/redacted: %s", got)
+	}
+	if len(login.Response.Cookies) != 1 || login.Response.Cookies[0].Name != "sid" || !login.Response.Cookies[0].HTTPOnly ||
+		login.Response.Cookies[0].Value != DefaultRedactReplacement {
+		t.Fatalf("unexpected response cookies: %+v", login.Response.Cookies)
+	}
+	if sum := login.Timings.Blocked + max(login.Timings.DNS, 0) + max(login.Timings.Connect, 0) +
+		login.Timings.Send + login.Timings.Wait + login.Timings.Receive; math.Abs(sum-login.Time) > 0.01 || login.Time <= 0 {
+		t.Fatalf("timings do not add up: %+v total=%v", login.Timings, login.Time)
+	}
+
+	// 第二个请求带上 CookieJar 中的 sid
+	me := entries[1]
+	if me.Response.Status != 404 || len(me.Request.Cookies) != 1 || me.Request.Cookies[0].Name != "sid" {
+		t.Fatalf("jar cookie not recorded: %+v", me.Request.Cookies)
+	}
+	if harHeader(me.Request.Headers, "Cookie") != DefaultRedactReplacement {
+		t.Fatalf("cookie header not redacted: %v", me.Request.Headers)
+	}
+	if !strings.Contains(me.Request.URL, "token="+DefaultRedactReplacement) || harHeader(me.Request.QueryString, "page") != "1" {
+		t.Fatalf("query not recorded/redacted: %s %v", me.Request.URL, me.Request.QueryString)
+	}
+
+	img := entries[2].Response.Content
+	if img.Encoding != "base64" || img.Size != 5 || img.MimeType != "image/png" {
+		t.Fatalf("binary content not base64 encoded: %+v", img)
+	}
+	if b, _ := base64.StdEncoding.DecodeString(img.Text); string(b) != "\x89PNG\x00" {
+		t.Fatalf("unexpected binary content: %q", b)
+	}
+}
+
+func TestHARRecorder_RedirectHops(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: "s1", Path: "/"})
+			http.Redirect(w, r, "/home?token=abc", http.StatusFound)
+		case "/home":
+			w.Write([]byte("welcome"))
+		}
+	}))
+	defer ts.Close()
+
+	rec := NewHARRecorder()
+	c := NewHttpClient(ts.URL)
+	c.SetHARRecorder(rec)
+	s := NewSession()
+	if _, err := c.DoPostWithSession(s, "/login", map[string]string{"user": "tom"}); err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	entries := rec.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("expected one entry per hop, got %d", len(entries))
+	}
+	login, home := entries[0], entries[1]
+	if login.Request.Method != "POST" || login.Response.Status != http.StatusFound {
+		t.Fatalf("unexpected first hop: %s %d", login.Request.Method, login.Response.Status)
+	}
+	if want := ts.URL + "/home?token=" + DefaultRedactReplacement; login.Response.RedirectURL != want {
+		t.Fatalf("redirectURL = %q, want %q", login.Response.RedirectURL, want)
+	}
+	if len(login.Response.Cookies) != 1 || login.Response.Cookies[0].Name != "sid" {
+		t.Fatalf("Set-Cookie of the redirect hop lost: %+v", login.Response.Cookies)
+	}
+	if home.Request.Method != "GET" || home.Response.Content.Text != "welcome" || home.Response.RedirectURL != "" {
+		t.Fatalf("unexpected second hop: %+v", home)
+	}
+	if len(home.Request.Cookies) != 1 || home.Request.Cookies[0].Name != "sid" {
+		t.Fatalf("cookie set by the redirect should be sent on the next hop: %+v", home.Request.Cookies)
+	}
+	if login.RequestID == "" || home.RequestID != login.RequestID {
+		t.Fatalf("hops should share the request id: %q %q", login.RequestID, home.RequestID)
+	}
+}
+
+func TestHARRecorder_OtherMethods(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodOptions:
+			w.Header().Set("Allow", "GET, HEAD")
+		case http.MethodPost:
+			r.ParseMultipartForm(1 << 20)
+		}
+		w.Write([]byte("file-data"))
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "up.bin")
+	os.WriteFile(src, []byte{0, 1, 2}, 0o644)
+
+	rec := NewHARRecorder()
+	c := NewHttpClient(ts.URL)
+	c.SetHARRecorder(rec)
+	if _, err := c.DoHead("/h"); err != nil {
+		t.Fatalf("DoHead failed: %v", err)
+	}
+	if _, err := c.DoOptions("/o"); err != nil {
+		t.Fatalf("DoOptions failed: %v", err)
+	}
+	if _, err := c.UploadFile("/u", "file", src, nil); err != nil {
+		t.Fatalf("UploadFile failed: %v", err)
+	}
+	if err := c.DownloadFile("/d", filepath.Join(dir, "down")); err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+
+	entries := rec.Entries()
+	if len(entries) != 4 {
+		t.Fatalf("expected 4 entries, got %d", len(entries))
+	}
+	for i, want := range []string{"HEAD", "OPTIONS", "POST", "GET"} {
+		if entries[i].Request.Method != want {
+			t.Errorf("entry %d: method %s, want %s", i, entries[i].Request.Method, want)
+		}
+	}
+	if entries[0].Response.BodySize != 0 {
+		t.Errorf("HEAD body size should be 0: %d", entries[0].Response.BodySize)
+	}
+	if harHeader(entries[1].Response.Headers, "Allow") != "GET, HEAD" {
+		t.Errorf("OPTIONS headers not recorded: %v", entries[1].Response.Headers)
+	}
+	if pd := entries[2].Request.PostData; pd == nil || pd.Text != "" || !strings.HasPrefix(pd.MimeType, "multipart/form-data") ||
+		!strings.Contains(pd.Comment, "binary") {
+		t.Errorf("upload body should be omitted as binary: %+v", pd)
+	}
+	if c := entries[3].Response.Content; c.Size != 9 || c.Text != "" || c.Comment == "" {
+		t.Errorf("download content should only record size: %+v", c)
+	}
+}
+
+func TestHARRecorder_FailureAndWriteFile(t *testing.T) {
+	rec := NewHARRecorder()
+	c := NewHttpClient("http://127.0.0.1:1")
+	c.DoGet("/not-recorded")
+	c.SetHARRecorder(rec)
+	c.DoGet("/fail")
+	c.SetHARRecorder(nil)
+	c.DoGet("/not-recorded")
+
+	entries := rec.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	if r := entries[0].Response; r.Status != 0 || !strings.Contains(r.Error, "connection refused") {
+		t.Fatalf("unexpected failure entry: %+v", r)
+	}
+
+	path := filepath.Join(t.TempDir(), "out.har")
+	if err := rec.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	var doc map[string]map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if doc["log"]["version"] != "1.2" || len(doc["log"]["entries"].([]interface{})) != 1 {
+		t.Fatalf("unexpected HAR document: %s", data)
+	}
+	entry := doc["log"]["entries"].([]interface{})[0].(map[string]interface{})
+	for _, key := range []string{"startedDateTime", "time", "request", "response", "cache", "timings"} {
+		if _, ok := entry[key]; !ok {
+			t.Errorf("entry missing required field %q", key)
+		}
+	}
+
+	rec.Reset()
+	if len(rec.HAR().Log.Entries) != 0 {
+		t.Fatal("Reset should clear entries")
+	}
+}
@@ -1,6 +1,9 @@
 package client
 
-import "net/textproto"
+import (
+	"net/http"
+	"net/textproto"
+)
 
 // SetHeader 批量设置请求头（已存在的 key 会被覆盖）。
 func (h *HttpClient) SetHeader(headers map[string]string) {
@@ -14,24 +17,109 @@ func (h *HttpClient) AddHeader(name, value string) {
 	h.setHeaderInternal(name, value)
 }
 
+// AppendHeader 追加一个请求头值（同名 header 保留已有值，语义同 http.Header.Add）。
+func (h *HttpClient) AppendHeader(name, value string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.headers == nil {
+		h.headers = make(http.Header)
+	}
+	h.headers.Add(name, value)
+	h.recordHeaderCase(name)
+}
+
+// SetHeaderValues 将指定请求头替换为给定的多个值。
+func (h *HttpClient) SetHeaderValues(name string, values ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.headers == nil {
+		h.headers = make(http.Header)
+	}
+	h.headers[textproto.CanonicalMIMEHeaderKey(name)] = append([]string(nil), values...)
+	h.recordHeaderCase(name)
+}
+
+// DelHeader 删除指定请求头的所有值。
+func (h *HttpClient) DelHeader(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	key := textproto.CanonicalMIMEHeaderKey(name)
+	h.headers.Del(key)
+	delete(h.headerCase, key)
+}
+
+// HeaderValues 返回指定请求头的所有值（副本）。
+func (h *HttpClient) HeaderValues(name string) []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return append([]string(nil), h.headers.Values(name)...)
+}
+
+// Headers 返回当前所有请求头（含多值）的副本（线程安全）。
+func (h *HttpClient) Headers() http.Header {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.headers.Clone()
+}
+
 // setHeaderInternal 线程安全地写入单个请求头（key 规范化为 Canonical-MIME 格式）。
 func (h *HttpClient) setHeaderInternal(name, value string) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	if h.headers == nil {
-		h.headers = make(map[string]string)
+		h.headers = make(http.Header)
+	}
+	h.headers.Set(name, value)
+	h.recordHeaderCase(name)
+}
+
+// recordHeaderCase 记录调用方传入的原始写法，调用方需持有写锁。
+func (h *HttpClient) recordHeaderCase(name string) {
+	if h.headerCase == nil {
+		h.headerCase = make(map[string]string)
 	}
-	h.headers[textproto.CanonicalMIMEHeaderKey(name)] = value
+	h.headerCase[textproto.CanonicalMIMEHeaderKey(name)] = name
 }
 
-// GetHeader 返回当前所有请求头的副本（线程安全）。
+// GetHeader 返回当前所有请求头的副本（线程安全）；多值 header 只返回第一个值。
 func (h *HttpClient) GetHeader() map[string]string {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
-	cp := make(map[string]string, len(h.headers))
-	for k, v := range h.headers {
-		cp[k] = v
+	return firstValues(h.headers)
+}
+
+// applyHeaders 将 client、Session（可为 nil）与单次请求的 header 写入 req，
+// 同名 header 按 请求 > Session > client 的优先级整体替换。
+func (h *HttpClient) applyHeaders(req *http.Request, s *Session, extra ...http.Header) {
+	header := h.Headers()
+	if s != nil {
+		for k, vs := range s.Headers() {
+			header[k] = vs // session header 优先级更高
+		}
 	}
-	return cp
+	raw := make(map[string]string)
+	for _, e := range extra {
+		for k, vs := range e {
+			key := textproto.CanonicalMIMEHeaderKey(k)
+			header[key] = append([]string(nil), vs...)
+			if key != k {
+				raw[key] = k
+			}
+		}
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	h.applyHeaderOrder(req, s, raw)
 }
 
+// firstValues 将 http.Header 转换为只保留首个值的 map。
+func firstValues(header http.Header) map[string]string {
+	cp := make(map[string]string, len(header))
+	for k, vs := range header {
+		if len(vs) > 0 {
+			cp[k] = vs[0]
+		}
+	}
+	return cp
+}
@@ -0,0 +1,319 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 请求头顺序与大小写保留。
+//
+// net/http 写出请求头时按 key 排序并统一为 Canonical-MIME 写法，指纹服务可据此识别。
+// 这里把期望的顺序/写法以内部 header 随请求下发，由连接层在 HTTP/1.1 请求头块
+// 写出前重排、还原大小写并剔除内部 header。
+//
+// 仅对明文 HTTP/1.1 与 JA3（uTLS，强制 http/1.1）连接生效；标准 TLS 可能协商 h2，
+// 且经 HTTP 代理访问 https 时 TLS 由标准库完成，这两种情况不附加布局信息。
+// 设置了 SetRoundTripper 或 UseCassette 时同样不附加。
+const (
+	headerOrderKey = "X-Req-Header-Order"
+	headerCaseKey  = "X-Req-Header-Case"
+)
+
+// SetHeaderOrder 设置请求头写出顺序，未列出的请求头保持原有顺序排在其后。
+// 名称大小写不敏感；开启 PreserveHeaderCase 时按此处写法输出。传空清除顺序。
+func (h *HttpClient) SetHeaderOrder(names ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.headerOrder = append([]string(nil), names...)
+}
+
+// PreserveHeaderCase 开启后按 SetHeader/AddHeader/SetHeaderOrder 传入的原始大小写写出请求头。
+func (h *HttpClient) PreserveHeaderCase(enable bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.preserveCase = enable
+}
+
+// headerLayout 合并 client 与 Session 的顺序/写法配置，Session 优先。
+func (h *HttpClient) headerLayout(s *Session) (order []string, names map[string]string, preserve bool) {
+	h.mu.RLock()
+	order = h.headerOrder
+	preserve = h.preserveCase
+	names = make(map[string]string, len(h.headerCase))
+	for _, n := range order {
+		names[textproto.CanonicalMIMEHeaderKey(n)] = n
+	}
+	for k, v := range h.headerCase {
+		names[k] = v
+	}
+	h.mu.RUnlock()
+
+	if s != nil {
+		s.mu.RLock()
+		if len(s.headerOrder) > 0 {
+			order = s.headerOrder
+			for _, n := range order {
+				names[textproto.CanonicalMIMEHeaderKey(n)] = n
+			}
+		}
+		for k, v := range s.headerCase {
+			names[k] = v
+		}
+		preserve = preserve || s.preserveCase
+		s.mu.RUnlock()
+	}
+	return order, names, preserve
+}
+
+// applyHeaderOrder 为可改写的连接附加顺序/写法信息；raw 为单次请求 header 的原始写法。
+func (h *HttpClient) applyHeaderOrder(req *http.Request, s *Session, raw map[string]string) {
+	order, names, preserve := h.headerLayout(s)
+	for k, v := range raw {
+		names[k] = v
+	}
+	if len(order) == 0 && !preserve {
+		return
+	}
+	if !h.wireRewritable(req) {
+		return
+	}
+	if len(order) > 0 {
+		req.Header.Set(headerOrderKey, strings.Join(order, ","))
+	}
+	if preserve {
+		var cased []string
+		for k := range req.Header {
+			if n, ok := names[k]; ok && n != k {
+				cased = append(cased, n)
+			}
+		}
+		if len(cased) > 0 {
+			req.Header.Set(headerCaseKey, strings.Join(cased, ","))
+		}
+	}
+}
+
+// wireRewritable 判断本次请求是否一定经由 orderedConn 以 HTTP/1.1 写出。
+// SetRoundTripper 的传输与 cassette 等外层包装会看到请求头，此时不附加内部 header。
+func (h *HttpClient) wireRewritable(req *http.Request) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.rtBase != nil || h.rtWrap != nil {
+		return false
+	}
+	switch req.URL.Scheme {
+	case "http":
+		return true
+	case "https":
+		return h.ja3Profile != "" && !h.httpProxy
+	}
+	return false
+}
+
+// checkRedirect 保留 http.Client 默认的 10 次上限。重定向会复制上一跳的请求头，
+// 新一跳不经 orderedConn（如 http 跳到标准 TLS 的 https）时剔除内部 header，避免发给服务端。
+func (h *HttpClient) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	if !h.wireRewritable(req) {
+		stripWireLayout(req.Header)
+	}
+	return nil
+}
+
+// stripWireLayout 删除请求头中的顺序/写法内部 header。
+func stripWireLayout(header http.Header) {
+	header.Del(headerOrderKey)
+	header.Del(headerCaseKey)
+}
+
+// orderedConn 在写出 HTTP/1.1 请求头块前按内部 header 重排并还原大小写。
+// 非 HTTP 明文（如 CONNECT 之后的 TLS 握手）自动切换为原样透传。
+type orderedConn struct {
+	net.Conn
+	mu        sync.Mutex
+	state     int
+	pending   []byte
+	remaining int64
+}
+
+const (
+	wireHead   = iota // 等待完整请求头块
+	wireBody          // 按 Content-Length 透传请求体
+	wireStream        // chunked 请求体，读到响应后回到 wireHead
+	wireRaw           // 非 HTTP 明文，永久透传
+)
+
+// maxHeaderBlock 请求头块缓冲上限，超出后放弃改写。
+const maxHeaderBlock = 1 << 20
+
+func newOrderedConn(conn net.Conn) net.Conn {
+	return &orderedConn{Conn: conn}
+}
+
+// NetConn 返回被包装的底层连接。
+func (c *orderedConn) NetConn() net.Conn {
+	return c.Conn
+}
+
+func (c *orderedConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		c.mu.Lock()
+		if c.state == wireStream {
+			c.state = wireHead
+		}
+		c.mu.Unlock()
+	}
+	return n, err
+}
+
+func (c *orderedConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	total := len(p)
+	for len(p) > 0 {
+		switch c.state {
+		case wireRaw, wireStream:
+			if _, err := c.Conn.Write(p); err != nil {
+				return 0, err
+			}
+			return total, nil
+		case wireBody:
+			n := int64(len(p))
+			if n > c.remaining {
+				n = c.remaining
+			}
+			if _, err := c.Conn.Write(p[:n]); err != nil {
+				return 0, err
+			}
+			c.remaining -= n
+			p = p[n:]
+			if c.remaining == 0 {
+				c.state = wireHead
+			}
+		default:
+			c.pending = append(c.pending, p...)
+			p = nil
+			if !looksLikeRequest(c.pending) || len(c.pending) > maxHeaderBlock {
+				c.state = wireRaw
+				p, c.pending = c.pending, nil
+				continue
+			}
+			end := bytes.Index(c.pending, []byte("\r\n\r\n"))
+			if end < 0 {
+				return total, nil
+			}
+			out, length, chunked := reorderHeaderBlock(c.pending[:end+4])
+			if _, err := c.Conn.Write(out); err != nil {
+				return 0, err
+			}
+			switch {
+			case chunked:
+				c.state = wireStream
+			case length > 0:
+				c.state = wireBody
+				c.remaining = length
+			}
+			p, c.pending = c.pending[end+4:], nil
+		}
+	}
+	return total, nil
+}
+
+// looksLikeRequest 判断缓冲区开头是否（可能）为 HTTP 请求行。
+func looksLikeRequest(b []byte) bool {
+	for i, ch := range b {
+		switch {
+		case ch == ' ':
+			return i > 0
+		case ch < 'A' || ch > 'Z':
+			return false
+		case i > 16:
+			return false
+		}
+	}
+	return true
+}
+
+// reorderHeaderBlock 重排请求头块，返回改写结果、Content-Length 及是否为 chunked。
+func reorderHeaderBlock(head []byte) ([]byte, int64, bool) {
+	lines := strings.Split(string(head[:len(head)-4]), "\r\n")
+	var (
+		order   []string
+		names   = make(map[string]string)
+		fields  []string
+		length  int64
+		chunked bool
+	)
+	for _, line := range lines[1:] {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			fields = append(fields, line)
+			continue
+		}
+		switch {
+		case strings.EqualFold(name, headerOrderKey):
+			order = splitHeaderList(value)
+			continue
+		case strings.EqualFold(name, headerCaseKey):
+			for _, n := range splitHeaderList(value) {
+				names[strings.ToLower(n)] = n
+			}
+			continue
+		case strings.EqualFold(name, "Content-Length"):
+			length, _ = strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		case strings.EqualFold(name, "Transfer-Encoding"):
+			chunked = strings.Contains(strings.ToLower(value), "chunked")
+		}
+		fields = append(fields, line)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(lines[0])
+	buf.WriteString("\r\n")
+	used := make([]bool, len(fields))
+	write := func(i int) {
+		used[i] = true
+		line := fields[i]
+		if name, value, ok := strings.Cut(line, ":"); ok {
+			if raw, ok := names[strings.ToLower(name)]; ok {
+				line = raw + ":" + value
+			}
+		}
+		buf.WriteString(line)
+		buf.WriteString("\r\n")
+	}
+	for _, want := range order {
+		for i, line := range fields {
+			if name, _, ok := strings.Cut(line, ":"); ok && !used[i] && strings.EqualFold(name, want) {
+				write(i)
+			}
+		}
+	}
+	for i := range fields {
+		if !used[i] {
+			write(i)
+		}
+	}
+	buf.WriteString("\r\n")
+	return buf.Bytes(), length, chunked
+}
+
+// splitHeaderList 拆分逗号分隔的 header 名称列表。
+func splitHeaderList(v string) []string {
+	var out []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,191 @@
+package client
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// rawHeaderServer 启动一个记录原始请求头块的 HTTP/1.1 服务端。
+func rawHeaderServer(t *testing.T) (addr string, heads <-chan []string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	ch := make(chan []string, 8)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					var lines []string
+					for {
+						line, err := r.ReadString('\n')
+						if err != nil {
+							return
+						}
+						line = strings.TrimRight(line, "\r\n")
+						if line == "" {
+							break
+						}
+						lines = append(lines, line)
+					}
+					ch <- lines
+					conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok"))
+				}
+			}(conn)
+		}
+	}()
+	return "http://" + ln.Addr().String(), ch
+}
+
+// headerNames 提取请求头块中的 header 名称（去掉请求行）。
+func headerNames(lines []string) []string {
+	var names []string
+	for _, l := range lines[1:] {
+		name, _, _ := strings.Cut(l, ":")
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestHeaderOrder_WireOrderAndCase(t *testing.T) {
+	addr, heads := rawHeaderServer(t)
+	c := NewHttpClient(addr)
+	c.SetHeader(map[string]string{"x-lower-case": "1", "X-Zeta": "z"})
+	c.SetHeaderOrder("X-Zeta", "user-agent", "Host")
+	c.PreserveHeaderCase(true)
+
+	if _, err := c.DoGet("/"); err != nil {
+		t.Fatalf("DoGet failed: %v", err)
+	}
+	names := headerNames(<-heads)
+	if len(names) < 3 || names[0] != "X-Zeta" || names[1] != "user-agent" || names[2] != "Host" {
+		t.Fatalf("unexpected header order: %v", names)
+	}
+	found := false
+	for _, n := range names {
+		if strings.EqualFold(n, headerOrderKey) || strings.EqualFold(n, headerCaseKey) {
+			t.Fatalf("internal header leaked: %v", names)
+		}
+		if n == "x-lower-case" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("raw header case not preserved: %v", names)
+	}
+}
+
+func TestHeaderOrder_SessionOverridesClient(t *testing.T) {
+	addr, heads := rawHeaderServer(t)
+	c := NewHttpClient(addr)
+	c.SetHeaderOrder("User-Agent")
+	s := NewSession()
+	s.SetHeader("X-First", "1")
+	s.SetHeaderOrder("X-First", "User-Agent")
+
+	if _, err := c.DoGetWithSession(s, "/"); err != nil {
+		t.Fatalf("DoGetWithSession failed: %v", err)
+	}
+	names := headerNames(<-heads)
+	if names[0] != "X-First" || names[1] != "User-Agent" {
+		t.Fatalf("session order not applied: %v", names)
+	}
+}
+
+func TestHeaderOrder_PostBodyOnKeepAlive(t *testing.T) {
+	addr, heads := rawHeaderServer(t)
+	c := NewHttpClient(addr)
+	c.SetHeaderOrder("Content-Type")
+	for i := 0; i < 2; i++ {
+		if _, err := c.DoPostRaw("/", "a=1&b=2"); err != nil {
+			t.Fatalf("DoPostRaw #%d failed: %v", i, err)
+		}
+		if names := headerNames(<-heads); names[0] != "Content-Type" {
+			t.Fatalf("request #%d order not applied: %v", i, names)
+		}
+	}
+}
+
+func TestHeaderOrder_NotAppliedWithoutRewritableConn(t *testing.T) {
+	c := NewHttpClient("https://example.com")
+	c.SetHeaderOrder("User-Agent")
+	req := httptest.NewRequest("GET", "https://example.com/", nil)
+	req.Header = make(http.Header)
+	c.applyHeaders(req, nil)
+	if req.Header.Get(headerOrderKey) != "" {
+		t.Fatal("order hint should not be attached on standard TLS")
+	}
+}
+
+func TestHeaderOrder_StrippedOnRedirectToStandardTLS(t *testing.T) {
+	var leaked []string
+	tlsSrv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, k := range []string{headerOrderKey, headerCaseKey} {
+			if v := r.Header.Get(k); v != "" {
+				leaked = append(leaked, k+": "+v)
+			}
+		}
+	}))
+	defer tlsSrv.Close()
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, tlsSrv.URL+"/next", http.StatusFound)
+	}))
+	defer plain.Close()
+
+	core, logs := observer.New(zap.DebugLevel)
+	c := NewHttpClient(plain.URL)
+	trustServer(t, c, tlsSrv, "")
+	c.SetLogger(zap.New(core).Sugar())
+	c.SetHeaderOrder("User-Agent", "Accept")
+	c.PreserveHeaderCase(true)
+	for _, s := range []*Session{nil, NewSession()} {
+		if _, err := c.Do(&Request{Path: "/", Header: http.Header{"x-lower": {"1"}}, Session: s}); err != nil {
+			t.Fatalf("Do failed: %v", err)
+		}
+	}
+	if len(leaked) > 0 {
+		t.Fatalf("internal headers reached the server after redirect: %v", leaked)
+	}
+	for _, e := range logs.All() {
+		if s := fmt.Sprint(e.ContextMap()); strings.Contains(s, "X-Req-Header") {
+			t.Fatalf("internal headers logged in %q: %s", e.Message, s)
+		}
+	}
+}
+
+func TestReorderHeaderBlock(t *testing.T) {
+	head := "POST / HTTP/1.1\r\nHost: a\r\nContent-Length: 5\r\nX-B: 2\r\n" +
+		headerOrderKey + ": x-b,host\r\n" + headerCaseKey + ": x-B\r\n\r\n"
+	out, length, chunked := reorderHeaderBlock([]byte(head))
+	want := "POST / HTTP/1.1\r\nx-B: 2\r\nHost: a\r\nContent-Length: 5\r\n\r\n"
+	if string(out) != want {
+		t.Fatalf("unexpected block:\n%q\nwant:\n%q", out, want)
+	}
+	if length != 5 || chunked {
+		t.Fatalf("expected length 5 without chunked, got %d %v", length, chunked)
+	}
+}
+
+func TestLooksLikeRequest(t *testing.T) {
+	if !looksLikeRequest([]byte("GET / HTTP/1.1")) {
+		t.Fatal("GET request line should be recognised")
+	}
+	if looksLikeRequest([]byte{0x16, 0x03, 0x01}) {
+		t.Fatal("TLS record should not be treated as HTTP")
+	}
+}
@@ -57,3 +57,37 @@ func TestSetHeader_Multiple(t *testing.T) {
 	}
 }
 
+func TestAppendHeader_MultiValue(t *testing.T) {
+	c := NewHttpClient("http://example.com")
+	c.AppendHeader("X-Forwarded-For", "1.1.1.1")
+	c.AppendHeader("x-forwarded-for", "2.2.2.2")
+	got := c.HeaderValues("X-Forwarded-For")
+	if len(got) != 2 || got[0] != "1.1.1.1" || got[1] != "2.2.2.2" {
+		t.Fatalf("expected two values, got %v", got)
+	}
+	// GetHeader 只保留第一个值
+	if c.GetHeader()["X-Forwarded-For"] != "1.1.1.1" {
+		t.Fatalf("GetHeader should return first value, got %v", c.GetHeader())
+	}
+}
+
+func TestSetHeaderValues_AndDelHeader(t *testing.T) {
+	c := NewHttpClient("http://example.com")
+	c.SetHeaderValues("Accept", "text/html", "application/json")
+	if len(c.Headers()["Accept"]) != 2 {
+		t.Fatalf("expected 2 Accept values, got %v", c.Headers()["Accept"])
+	}
+	c.DelHeader("accept")
+	if len(c.HeaderValues("Accept")) != 0 {
+		t.Fatal("DelHeader should remove all values")
+	}
+}
+
+func TestHeaders_ReturnsCopy(t *testing.T) {
+	c := NewHttpClient("http://example.com")
+	c.AppendHeader("X-A", "1")
+	c.Headers().Add("X-A", "2")
+	if len(c.HeaderValues("X-A")) != 1 {
+		t.Fatal("Headers should return a copy")
+	}
+}
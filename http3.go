@@ -0,0 +1,421 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// HTTP/3（QUIC）传输。
+//
+// 开启后 https 请求先走 TCP 传输，响应带 Alt-Svc: h3 时记录该站点的 HTTP/3 端点（HTTP/3 响应同样刷新），
+// 后续请求改用 QUIC；QUIC 握手或请求失败时自动回退到 HTTP/1.1/2，并在一段时间内不再尝试。
+// HTTP/3 位于 send 的 client.Do 之下，并发限速、重试与日志对两种协议一致。
+// QUIC 握手使用标准 TLS，对 HTTP/3 源站 EnableHTTP3 优先于 EnableJA3 与 SetHeaderOrder：
+// 指纹与请求头顺序改写仅在 TCP 请求（含回退）上生效，HTTP/3 请求剔除其内部 header。
+// QUIC 连接沿用 DNS 与出口地址设置；配置了代理或 Unix socket 时不尝试 HTTP/3，请求仍走 TCP。
+
+// HTTP3Config HTTP/3 配置。
+type HTTP3Config struct {
+	Force            bool          // 不等待 Alt-Svc，https 请求直接尝试 HTTP/3
+	HandshakeTimeout time.Duration // QUIC 握手超时，0 使用默认 3s
+	BrokenTimeout    time.Duration // HTTP/3 失败后回退 TCP 的时长，0 使用默认 5 分钟
+}
+
+const (
+	defaultH3HandshakeTimeout = 3 * time.Second
+	defaultH3BrokenTimeout    = 5 * time.Minute
+	defaultAltSvcMaxAge       = 24 * time.Hour
+)
+
+// altSvcEntry 某个 https 源站的 HTTP/3 端点。
+type altSvcEntry struct {
+	authority string    // QUIC 拨号地址 host:port
+	expires   time.Time // Alt-Svc ma 到期时间
+	broken    time.Time // 非零时在该时间前不再尝试 HTTP/3
+}
+
+// http3State HTTP/3 传输及 Alt-Svc 缓存，client 与其 Session 共享。
+type http3State struct {
+	h         *HttpClient
+	transport *http3.Transport
+	force     bool
+	brokenFor time.Duration
+
+	mu     sync.Mutex
+	altSvc map[string]*altSvcEntry // 源站 host:port -> 端点
+}
+
+// EnableHTTP3 开启 HTTP/3 支持（默认通过 Alt-Svc 发现），可选 cfg 调整行为。
+func (h *HttpClient) EnableHTTP3(cfg ...*HTTP3Config) {
+	c := &HTTP3Config{}
+	if len(cfg) > 0 && cfg[0] != nil {
+		c = cfg[0]
+	}
+	st := &http3State{
+		h:         h,
+		force:     c.Force,
+		brokenFor: c.BrokenTimeout,
+		altSvc:    make(map[string]*altSvcEntry),
+	}
+	if st.brokenFor <= 0 {
+		st.brokenFor = defaultH3BrokenTimeout
+	}
+	handshake := c.HandshakeTimeout
+	if handshake <= 0 {
+		handshake = defaultH3HandshakeTimeout
+	}
+	st.transport = &http3.Transport{
+		QUICConfig: &quic.Config{HandshakeIdleTimeout: handshake},
+		Dial:       st.dial,
+	}
+
+	h.mu.Lock()
+	old := h.h3
+	h.h3 = st
+	ja3, ordered := h.ja3Profile != "", len(h.headerOrder) > 0 || h.preserveCase
+	h.mu.Unlock()
+	if reason := st.bypass(""); reason != "" {
+		h.LogInfo("HTTP/3 不支持代理与 Unix socket，当前配置下请求仍走 TCP", "reason", reason)
+	}
+	if old != nil {
+		old.transport.Close()
+	}
+	h.client.Transport = h.roundTripperFor(h.transport)
+	h.LogInfo("HTTP/3 enabled", "force", c.Force)
+	if ja3 || ordered {
+		h.LogInfo("HTTP/3 连接使用标准 TLS，JA3 指纹与请求头顺序改写仅作用于 TCP 请求", "ja3", ja3, "header_order", ordered)
+	}
+}
+
+// DisableHTTP3 关闭 HTTP/3，恢复仅使用 TCP 传输。
+func (h *HttpClient) DisableHTTP3() {
+	h.mu.Lock()
+	old := h.h3
+	h.h3 = nil
+	h.mu.Unlock()
+	h.client.Transport = h.roundTripperFor(h.transport)
+	if old != nil {
+		old.transport.Close()
+	}
+}
+
+// roundTripperFor 返回包装了 HTTP/3、cassette 与 HAR 记录等外层 RoundTripper 的传输（均未开启时原样返回 t）；
+// SetRoundTripper 设置的传输替代 t 与 HTTP/3。
+func (h *HttpClient) roundTripperFor(t *http.Transport) http.RoundTripper {
+	h.mu.RLock()
+	st, base, wrap, har := h.h3, h.rtBase, h.rtWrap, h.har
+	h.mu.RUnlock()
+	var rt http.RoundTripper = t
+	switch {
+	case base != nil:
+		rt = base
+	case st != nil:
+		rt = st.roundTripper(t)
+	}
+	if wrap != nil {
+		rt = wrap(rt)
+	}
+	if har != nil {
+		rt = &harTransport{h: h, rec: har, next: rt}
+	}
+	return rt
+}
+
+// setRoundTripperWrap 设置最外层的 RoundTripper 包装，nil 表示不包装；Session 的请求同样生效。
+func (h *HttpClient) setRoundTripperWrap(wrap func(http.RoundTripper) http.RoundTripper) {
+	h.mu.Lock()
+	h.rtWrap = wrap
+	h.mu.Unlock()
+	h.client.Transport = h.roundTripperFor(h.transport)
+}
+
+func (st *http3State) roundTripper(next http.RoundTripper) http.RoundTripper {
+	return &h3RoundTripper{st: st, next: next}
+}
+
+// dial 按 Alt-Svc 端点建立 QUIC 连接，TLS 配置取自 SetTLSConfig 的当前设置。
+func (st *http3State) dial(ctx context.Context, addr string, tlsCfg *tls.Config, cfg *quic.Config) (*quic.Conn, error) {
+	st.h.mu.RLock()
+	base := st.h.tlsConfig
+	st.h.mu.RUnlock()
+	if base != nil {
+		c := base.Clone()
+		if c.ServerName == "" {
+			c.ServerName = tlsCfg.ServerName
+		}
+		c.NextProtos = tlsCfg.NextProtos
+		tlsCfg = c
+	}
+	target := addr
+	st.mu.Lock()
+	if e, ok := st.altSvc[addr]; ok && e.authority != "" {
+		target = e.authority
+	}
+	st.mu.Unlock()
+
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.TLSHandshakeStart != nil {
+		trace.TLSHandshakeStart()
+	}
+	conn, err := st.dialResolved(ctx, target, tlsCfg, cfg)
+	if trace != nil && trace.TLSHandshakeDone != nil {
+		var state tls.ConnectionState
+		if conn != nil {
+			state = conn.ConnectionState().TLS
+		}
+		trace.TLSHandshakeDone(state, err)
+	}
+	return conn, err
+}
+
+// dialResolved 按 DNS 设置解析目标主机，依次尝试各 IP 建立 QUIC 连接。
+func (st *http3State) dialResolved(ctx context.Context, target string, tlsCfg *tls.Config, cfg *quic.Config) (*quic.Conn, error) {
+	host, port, err := net.SplitHostPort(target)
+	if err != nil {
+		return nil, err
+	}
+	portNum, err := net.LookupPort("udp", port)
+	if err != nil {
+		return nil, err
+	}
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		lookup := lookupSystem
+		if st.h.dns != nil {
+			lookup = st.h.dns.lookup
+		}
+		if ips, err = lookup(ctx, host); err != nil {
+			return nil, err
+		}
+	}
+	var firstErr error
+	for _, ip := range ips {
+		conn, err := st.dialUDP(ctx, &net.UDPAddr{IP: ip, Port: portNum}, tlsCfg, cfg)
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if firstErr == nil {
+		firstErr = &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return nil, firstErr
+}
+
+// dialUDP 在按出口地址设置绑定的 UDP 端口上建立 QUIC 连接，连接关闭时释放该端口。
+func (st *http3State) dialUDP(ctx context.Context, addr *net.UDPAddr, tlsCfg *tls.Config, cfg *quic.Config) (*quic.Conn, error) {
+	udp, err := net.ListenUDP("udp", &net.UDPAddr{IP: st.h.connLocalAddr(addr.IP.String())})
+	if err != nil {
+		return nil, err
+	}
+	tr := &quic.Transport{Conn: udp}
+	conn, err := tr.DialEarly(ctx, addr, tlsCfg, cfg)
+	if err != nil {
+		tr.Close()
+		udp.Close()
+		return nil, err
+	}
+	go func() {
+		<-conn.Context().Done()
+		tr.Close()
+		udp.Close()
+	}()
+	return conn, nil
+}
+
+// bypass 返回拨向 origin 时不能走 QUIC 的原因（代理或 Unix socket），可用时为空。
+func (st *http3State) bypass(origin string) string {
+	h := st.h
+	h.mu.RLock()
+	proxied := h.proxyCfg != nil || h.proxyDial != nil || h.httpProxy
+	h.mu.RUnlock()
+	switch {
+	case proxied:
+		return "proxy"
+	case h.unixSocketFor(origin) != "":
+		return "unix_socket"
+	}
+	return ""
+}
+
+// usable 判断源站当前是否应尝试 HTTP/3。
+func (st *http3State) usable(origin string) bool {
+	if st.bypass(origin) != "" {
+		return false
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	now := time.Now()
+	e, ok := st.altSvc[origin]
+	if ok && now.Before(e.broken) {
+		return false
+	}
+	if st.force {
+		return true
+	}
+	if !ok || e.authority == "" {
+		return false
+	}
+	if now.After(e.expires) {
+		delete(st.altSvc, origin)
+		return false
+	}
+	return true
+}
+
+// markBroken HTTP/3 失败后暂停对该源站的尝试。
+func (st *http3State) markBroken(origin string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	e, ok := st.altSvc[origin]
+	if !ok {
+		e = &altSvcEntry{}
+		st.altSvc[origin] = e
+	}
+	e.broken = time.Now().Add(st.brokenFor)
+}
+
+// learn 根据响应的 Alt-Svc 头更新源站的 HTTP/3 端点。
+func (st *http3State) learn(origin string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	authority, maxAge, clear := parseAltSvc(values, origin)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	e, ok := st.altSvc[origin]
+	switch {
+	case clear:
+		if ok {
+			e.authority = ""
+		}
+	case authority != "":
+		if !ok {
+			e = &altSvcEntry{}
+			st.altSvc[origin] = e
+		}
+		e.authority = authority
+		e.expires = time.Now().Add(maxAge)
+	}
+}
+
+// parseAltSvc 从 Alt-Svc 头中取第一个 h3 端点；clear 表示服务端撤销全部备用服务。
+func parseAltSvc(values []string, origin string) (authority string, maxAge time.Duration, clear bool) {
+	originHost, _, _ := net.SplitHostPort(origin)
+	for _, v := range values {
+		for _, alt := range strings.Split(v, ",") {
+			parts := strings.Split(strings.TrimSpace(alt), ";")
+			first := strings.TrimSpace(parts[0])
+			if first == "clear" {
+				return "", 0, true
+			}
+			proto, value, ok := strings.Cut(first, "=")
+			if !ok || strings.TrimSpace(proto) != "h3" {
+				continue
+			}
+			host, port, err := net.SplitHostPort(strings.Trim(strings.TrimSpace(value), `"`))
+			if err != nil {
+				continue
+			}
+			if host == "" {
+				host = originHost
+			}
+			maxAge = defaultAltSvcMaxAge
+			for _, p := range parts[1:] {
+				k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+				if strings.TrimSpace(k) == "ma" {
+					if n, err := strconv.Atoi(strings.Trim(strings.TrimSpace(v), `"`)); err == nil {
+						maxAge = time.Duration(n) * time.Second
+					}
+				}
+			}
+			return net.JoinHostPort(host, port), maxAge, false
+		}
+	}
+	return "", 0, false
+}
+
+// h3RoundTripper 可用时走 HTTP/3，失败回退到 next，并从 next 的响应中学习 Alt-Svc。
+type h3RoundTripper struct {
+	st   *http3State
+	next http.RoundTripper
+}
+
+func (rt *h3RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "https" {
+		return rt.next.RoundTrip(req)
+	}
+	origin := req.URL.Host
+	if req.URL.Port() == "" {
+		origin = net.JoinHostPort(req.URL.Hostname(), "443")
+	}
+
+	if rt.st.usable(origin) {
+		res, err := rt.st.transport.RoundTrip(withoutWireLayout(req))
+		if err == nil {
+			rt.st.learn(origin, res.Header.Values("Alt-Svc"))
+			return res, nil
+		}
+		if req.Context().Err() != nil {
+			return nil, err
+		}
+		rt.st.markBroken(origin)
+		rt.st.h.LogInfo("HTTP/3 请求失败，回退到 TCP",
+			"request_id", RequestIDFromContext(req.Context()),
+			"origin", origin,
+			"error", err,
+		)
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return nil, err
+			}
+			body, gerr := req.GetBody()
+			if gerr != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+
+	res, err := rt.next.RoundTrip(req)
+	if err == nil {
+		rt.st.learn(origin, res.Header.Values("Alt-Svc"))
+	}
+	return res, err
+}
+
+// withoutWireLayout 去掉请求头顺序/写法的内部 header：QUIC 连接不经 orderedConn，
+// 不剔除会原样发给服务端。回退 TCP 时仍使用原请求。
+func withoutWireLayout(req *http.Request) *http.Request {
+	if req.Header.Get(headerOrderKey) == "" && req.Header.Get(headerCaseKey) == "" {
+		return req
+	}
+	r := req.Clone(req.Context())
+	r.Body = req.Body
+	stripWireLayout(r.Header)
+	return r
+}
+
+// CloseIdleConnections 同时关闭 TCP 与 QUIC 空闲连接。
+func (rt *h3RoundTripper) CloseIdleConnections() {
+	rt.st.transport.CloseIdleConnections()
+	if c, ok := rt.next.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,217 @@
+package client
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// startH3Server 启动同证书的 TCP（TLS）与 QUIC 服务端，TCP 响应通过 Alt-Svc 通告 QUIC 端口；
+// 可选 inspect 在响应前查看请求。
+func startH3Server(t *testing.T, inspect ...func(*http.Request)) *httptest.Server {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen udp failed: %v", err)
+	}
+	udpPort := pc.LocalAddr().(*net.UDPAddr).Port
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, f := range inspect {
+			f(r)
+		}
+		w.Header().Set("Alt-Svc", `h3=":`+strconv.Itoa(udpPort)+`"; ma=60`)
+		w.Write([]byte(r.Proto))
+	})
+	ts := httptest.NewTLSServer(handler)
+	h3 := &http3.Server{
+		Handler:   handler,
+		TLSConfig: http3.ConfigureTLSConfig(&tls.Config{Certificates: ts.TLS.Certificates}),
+	}
+	go h3.Serve(pc)
+	t.Cleanup(func() {
+		h3.Close()
+		pc.Close()
+		ts.Close()
+	})
+	return ts
+}
+
+func TestHTTP3_AltSvcDiscovery(t *testing.T) {
+	ts := startH3Server(t)
+	c := NewHttpClient(ts.URL)
+	trustServer(t, c, ts, "")
+	c.EnableHTTP3()
+	defer c.DisableHTTP3()
+
+	var protos []string
+	for i := 0; i < 2; i++ {
+		resp, err := c.Do(&Request{Method: "POST", Path: "/", Body: []byte("payload")})
+		if err != nil {
+			t.Fatalf("Do #%d failed: %v", i, err)
+		}
+		protos = append(protos, string(resp.Body))
+		if i == 1 && (resp.Conn.Proto != "HTTP/3.0" || resp.Conn.ALPN != "h3") {
+			t.Fatalf("conn info should report HTTP/3, got %+v", resp.Conn)
+		}
+	}
+	if protos[0] == "HTTP/3.0" || protos[1] != "HTTP/3.0" {
+		t.Fatalf("expected TCP then HTTP/3, got %v", protos)
+	}
+
+	s := NewSession()
+	body, err := c.DoGetWithSession(s, "/")
+	if err != nil {
+		t.Fatalf("DoGetWithSession failed: %v", err)
+	}
+	if string(body) != "HTTP/3.0" {
+		t.Fatalf("sessions should share the Alt-Svc cache, got %s", body)
+	}
+}
+
+func TestHTTP3_StripsHeaderLayoutOverQUIC(t *testing.T) {
+	var mu sync.Mutex
+	var leaked []string
+	ts := startH3Server(t, func(r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, k := range []string{headerOrderKey, headerCaseKey} {
+			if r.Header.Get(k) != "" {
+				leaked = append(leaked, r.Proto+" "+k)
+			}
+		}
+	})
+	c := NewHttpClient(ts.URL)
+	trustServer(t, c, ts, "chrome")
+	c.SetHeaderOrder("user-agent", "x-token")
+	c.PreserveHeaderCase(true)
+	c.EnableHTTP3()
+	defer c.DisableHTTP3()
+
+	var protos []string
+	for i := 0; i < 2; i++ {
+		resp, err := c.Do(&Request{Method: "GET", Path: "/", Header: http.Header{"X-Token": {"t"}}})
+		if err != nil {
+			t.Fatalf("Do #%d failed: %v", i, err)
+		}
+		protos = append(protos, string(resp.Body))
+	}
+	if protos[1] != "HTTP/3.0" {
+		t.Fatalf("expected the second request over HTTP/3, got %v", protos)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(leaked) > 0 {
+		t.Fatalf("internal header layout keys reached the server: %v", leaked)
+	}
+}
+
+func TestHTTP3_ForceFallsBackToTCP(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	defer ts.Close()
+
+	c := NewHttpClient(ts.URL)
+	trustServer(t, c, ts, "")
+	c.EnableHTTP3(&HTTP3Config{Force: true, HandshakeTimeout: 200 * time.Millisecond})
+	defer c.DisableHTTP3()
+
+	// 该端口没有 QUIC 服务，握手超时后回退到 TCP
+	resp, err := c.Do(&Request{Method: "PUT", Path: "/", Body: []byte("data")})
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if string(resp.Body) == "HTTP/3.0" {
+		t.Fatalf("expected TCP fallback, got %s", resp.Body)
+	}
+	start := time.Now()
+	if _, err := c.DoGet("/"); err != nil {
+		t.Fatalf("DoGet failed: %v", err)
+	}
+	if time.Since(start) > 150*time.Millisecond {
+		t.Fatal("broken origin should skip HTTP/3 without waiting for another handshake")
+	}
+}
+
+func TestHTTP3_UsesConfiguredResolver(t *testing.T) {
+	ts := startH3Server(t)
+	_, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+	// h3.test 仅能经 Resolve 解析，QUIC 拨号须走同一解析器才能连上
+	c := NewHttpClientWithTransport("https://h3.test:"+port, &TransportConfig{
+		Resolve: map[string]string{"h3.test": "127.0.0.1"},
+	})
+	if err := c.SetTLSConfig(&TLSConfig{RootCAPEM: serverCAPEM(ts), ServerName: "example.com"}); err != nil {
+		t.Fatalf("SetTLSConfig failed: %v", err)
+	}
+	c.EnableHTTP3()
+	defer c.DisableHTTP3()
+
+	var protos []string
+	for i := 0; i < 2; i++ {
+		body, err := c.DoGet("/")
+		if err != nil {
+			t.Fatalf("DoGet #%d failed: %v", i, err)
+		}
+		protos = append(protos, string(body))
+	}
+	if protos[1] != "HTTP/3.0" {
+		t.Fatalf("expected the second request over HTTP/3, got %v", protos)
+	}
+}
+
+func TestHTTP3_SkippedWithProxyOrUnixSocket(t *testing.T) {
+	origin := "example.com:443"
+	core, logs := observer.New(zap.InfoLevel)
+	c := NewHttpClient("https://example.com")
+	c.SetLogger(zap.New(core).Sugar())
+	if err := c.SetProxy(&ProxyConfig{Type: "http", Address: "127.0.0.1:1"}); err != nil {
+		t.Fatalf("SetProxy failed: %v", err)
+	}
+	c.EnableHTTP3(&HTTP3Config{Force: true})
+	defer c.DisableHTTP3()
+	if c.h3.usable(origin) {
+		t.Fatal("HTTP/3 should be skipped behind a proxy")
+	}
+	if logs.FilterField(zap.String("reason", "proxy")).Len() == 0 {
+		t.Fatal("EnableHTTP3 should log that the proxy disables HTTP/3")
+	}
+	c.SetProxy(nil)
+	if !c.h3.usable(origin) {
+		t.Fatal("HTTP/3 should be usable once the proxy is cleared")
+	}
+
+	u := NewHttpClientWithTransport("https://example.com", &TransportConfig{UnixSocket: "/tmp/req.sock"})
+	u.EnableHTTP3(&HTTP3Config{Force: true})
+	defer u.DisableHTTP3()
+	if u.h3.usable(origin) {
+		t.Fatal("HTTP/3 should be skipped over a unix socket")
+	}
+}
+
+func TestParseAltSvc(t *testing.T) {
+	cases := []struct {
+		header    string
+		authority string
+		maxAge    time.Duration
+		clear     bool
+	}{
+		{`h3=":443"; ma=3600`, "example.com:443", time.Hour, false},
+		{`h2=":443", h3="alt.example.com:8443"`, "alt.example.com:8443", defaultAltSvcMaxAge, false},
+		{`h3-29=":443"`, "", 0, false},
+		{`clear`, "", 0, true},
+	}
+	for _, tc := range cases {
+		a, ma, clear := parseAltSvc([]string{tc.header}, "example.com:443")
+		if a != tc.authority || ma != tc.maxAge || clear != tc.clear {
+			t.Errorf("parseAltSvc(%q) = %q %v %v", tc.header, a, ma, clear)
+		}
+	}
+}
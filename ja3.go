@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"math/rand/v2"
+	"net"
+	"net/http"
+
+	utls "github.com/refraction-networking/utls"
+)
+
+// JA3 指纹轮换。
+//
+// 配置了候选 profile 后：
+//   - client 级别的请求按 JA3Config.Rotate 在每条新连接或整个 client 生命周期内抽取一次；
+//   - 每个 Session 首次发请求时抽取一次并固定，且使用独立连接池，
+//     保证同一会话（如登录流程）的指纹不会中途变化。
+const (
+	JA3RotateConnection = "connection" // 每条新连接重新抽取
+	JA3RotateSession    = "session"    // client 级别固定抽取一次（默认）
+)
+
+// pickJA3 按权重从候选 profile 中抽取一个；候选为空时返回 fallback。
+func pickJA3(pool []JA3Profile, fallback string) string {
+	total := 0
+	for _, p := range pool {
+		total += ja3Weight(p)
+	}
+	if total == 0 {
+		return fallback
+	}
+	n := rand.IntN(total)
+	for _, p := range pool {
+		if n -= ja3Weight(p); n < 0 {
+			return p.Name
+		}
+	}
+	return fallback
+}
+
+// inJA3Pool 判断 name 是否为候选 profile 之一。
+func inJA3Pool(pool []JA3Profile, name string) bool {
+	for _, p := range pool {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func ja3Weight(p JA3Profile) int {
+	if p.Weight <= 0 {
+		return 1
+	}
+	return p.Weight
+}
+
+// connJA3Profile 返回新建 client 级别连接应使用的 profile。
+func (h *HttpClient) connJA3Profile() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.ja3Rotate == JA3RotateConnection {
+		return pickJA3(h.ja3Pool, h.ja3Profile)
+	}
+	return h.ja3Profile
+}
+
+// profileSessionCache 按 profile 隔离会话票据：轮换池内各 profile 共用同一个底层缓存，
+// 若票据跨 profile 复用，服务端可据此把不同指纹关联为同一客户端。
+type profileSessionCache struct {
+	cache   utls.ClientSessionCache
+	profile string
+}
+
+// sessionCacheFor 返回按 profile 隔离的会话缓存；cache 为 nil 时返回 nil。
+func sessionCacheFor(cache utls.ClientSessionCache, profile string) utls.ClientSessionCache {
+	if cache == nil {
+		return nil
+	}
+	return profileSessionCache{cache: cache, profile: profile}
+}
+
+func (c profileSessionCache) Get(sessionKey string) (*utls.ClientSessionState, bool) {
+	return c.cache.Get(c.profile + "|" + sessionKey)
+}
+
+func (c profileSessionCache) Put(sessionKey string, cs *utls.ClientSessionState) {
+	c.cache.Put(c.profile+"|"+sessionKey, cs)
+}
+
+// transportFor 返回 Session 应使用的 transport。以下情况为 Session 独占的连接池，否则与 client 共享：
+//   - JA3 模式下启用了指纹轮换或 Session 独立的 TLS 会话缓存；
+//   - 出口源地址按 Session 粘滞（LocalAddrRotateSession）。
+func (h *HttpClient) transportFor(s *Session) *http.Transport {
+	h.mu.RLock()
+	pool, fallback, gen, cache, local := h.ja3Pool, h.ja3Profile, h.dialGen, h.tlsSessionCache, h.localAddrs
+	h.mu.RUnlock()
+	stickyLocal := local != nil && local.sticky
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ownTLS := fallback != "" && (len(pool) > 0 || s.tlsSessionCache != nil)
+	if !ownTLS && !stickyLocal {
+		return h.transport
+	}
+	if s.transport != nil && s.transportGen == gen {
+		return s.transport
+	}
+	if s.transport != nil {
+		s.transport.CloseIdleConnections()
+	}
+	// 代理、TLS 等配置变化时重建连接池，但沿用已固定的指纹与出口地址，保证会话内不变
+	opts := connOptions{ja3: s.ja3Profile, sessionCache: cache}
+	if !inJA3Pool(pool, opts.ja3) {
+		opts.ja3 = pickJA3(pool, fallback)
+	}
+	if s.tlsSessionCache != nil {
+		opts.sessionCache = s.tlsSessionCache
+	}
+	if len(pool) > 0 {
+		s.ja3Profile = opts.ja3
+	}
+	if stickyLocal {
+		if !s.localSeeded {
+			s.localSeed, s.localSeeded = local.seed(), true
+		}
+		seed := s.localSeed
+		opts.localAddr = func(host string) net.IP { return local.pick(seed, host) }
+	}
+	t := h.transport.Clone()
+	t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return h.dialContextWith(ctx, network, addr, opts)
+	}
+	if fallback != "" {
+		t.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return h.dialTLSWith(ctx, network, addr, opts)
+		}
+	}
+	s.transport = t
+	s.transportGen = gen
+	return t
+}
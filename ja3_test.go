@@ -0,0 +1,108 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPickJA3_Weighted(t *testing.T) {
+	pool := []JA3Profile{{Name: "chrome", Weight: 1}, {Name: "firefox", Weight: 0}}
+	seen := map[string]int{}
+	for i := 0; i < 200; i++ {
+		seen[pickJA3(pool, "safari")]++
+	}
+	if seen["chrome"] == 0 || seen["firefox"] == 0 {
+		t.Fatalf("both profiles should be picked, got %v", seen)
+	}
+	if seen["safari"] != 0 {
+		t.Fatalf("fallback should not be used with non-empty pool, got %v", seen)
+	}
+	if got := pickJA3(nil, "safari"); got != "safari" {
+		t.Fatalf("empty pool should return fallback, got %s", got)
+	}
+}
+
+func TestEnableJA3_InvalidRotate(t *testing.T) {
+	c := NewHttpClient("https://example.com")
+	if err := c.EnableJA3("chrome", &JA3Config{Rotate: "request"}); err == nil {
+		t.Fatal("expected error for unsupported rotate mode")
+	}
+}
+
+func TestEnableJA3_RotateConnection(t *testing.T) {
+	c := NewHttpClient("https://example.com")
+	pool := []JA3Profile{{Name: "chrome"}, {Name: "firefox"}, {Name: "random"}}
+	if err := c.EnableJA3("chrome", &JA3Config{Profiles: pool, Rotate: JA3RotateConnection}); err != nil {
+		t.Fatalf("EnableJA3 failed: %v", err)
+	}
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		seen[c.connJA3Profile()] = true
+	}
+	if len(seen) < 2 {
+		t.Fatalf("per-connection rotation should vary profiles, got %v", seen)
+	}
+}
+
+func TestTransportFor_SessionSticky(t *testing.T) {
+	c := NewHttpClient("https://example.com")
+	if c.transportFor(NewSession()) != c.transport {
+		t.Fatal("session should share client transport without rotation")
+	}
+	pool := []JA3Profile{{Name: "chrome"}, {Name: "firefox"}}
+	if err := c.EnableJA3("chrome", &JA3Config{Profiles: pool}); err != nil {
+		t.Fatalf("EnableJA3 failed: %v", err)
+	}
+	s := NewSession()
+	t1 := c.transportFor(s)
+	profile := s.JA3Profile()
+	if t1 == c.transport || profile == "" {
+		t.Fatal("session should get its own transport and profile")
+	}
+	for i := 0; i < 10; i++ {
+		if c.transportFor(s) != t1 || s.JA3Profile() != profile {
+			t.Fatal("session fingerprint should stay sticky")
+		}
+	}
+	c.DisableJA3()
+	if c.transportFor(s) != c.transport {
+		t.Fatal("session should fall back to shared transport after DisableJA3")
+	}
+}
+
+func TestTransportFor_SessionFollowsProxyChange(t *testing.T) {
+	newProxy := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name + " " + r.URL.String()))
+		}))
+	}
+	pa, pb := newProxy("a"), newProxy("b")
+	defer pa.Close()
+	defer pb.Close()
+
+	c := NewHttpClient("http://target.invalid")
+	pool := []JA3Profile{{Name: "chrome"}, {Name: "firefox"}}
+	if err := c.EnableJA3("chrome", &JA3Config{Profiles: pool}); err != nil {
+		t.Fatalf("EnableJA3 failed: %v", err)
+	}
+	s := NewSession()
+	for _, tc := range []struct{ proxy, want string }{
+		{pa.Listener.Addr().String(), "a http://target.invalid/x"},
+		{pb.Listener.Addr().String(), "b http://target.invalid/x"},
+	} {
+		if err := c.SetProxy(&ProxyConfig{Type: "http", Address: tc.proxy}); err != nil {
+			t.Fatalf("SetProxy failed: %v", err)
+		}
+		body, err := c.DoGetWithSession(s, "/x")
+		if err != nil || string(body) != tc.want {
+			t.Fatalf("got %q, %v; want %q", body, err, tc.want)
+		}
+	}
+	profile := s.JA3Profile()
+	c.SetProxy(nil)
+	c.transportFor(s)
+	if s.JA3Profile() != profile {
+		t.Fatal("rebuilding the session transport should keep its fingerprint")
+	}
+}
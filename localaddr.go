@@ -0,0 +1,117 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"sync/atomic"
+)
+
+// 出口源地址轮换。
+//
+// 地址池对直连与 uTLS 拨号（含连接 HTTP 代理）生效，SOCKS5 代理由代理端决定出口地址。
+// 每次拨号只会选用与目标地址同族（IPv4/IPv6）的源地址；目标为主机名时先解析，
+// 再逐个目标 IP 按其地址族选择源地址。
+const (
+	LocalAddrRotateConnection = "connection" // 每条新连接轮询下一个地址（默认）
+	LocalAddrRotateSession    = "session"    // 每个 Session 固定一个地址并使用独占连接池
+)
+
+// localAddrPool 出口源地址池。
+type localAddrPool struct {
+	ips    []net.IP
+	sticky bool
+	next   atomic.Uint64
+}
+
+// seed 返回下一个轮询起点。
+func (p *localAddrPool) seed() uint64 {
+	return p.next.Add(1) - 1
+}
+
+// pick 从起点 n 开始选出第一个与目标 host 同族的地址；host 不是 IP（如未经解析的代理地址）时不限地址族。
+func (p *localAddrPool) pick(n uint64, host string) net.IP {
+	want4, anyFamily := false, true
+	if ip := net.ParseIP(host); ip != nil {
+		want4, anyFamily = ip.To4() != nil, false
+	}
+	for i := range p.ips {
+		ip := p.ips[(n+uint64(i))%uint64(len(p.ips))]
+		if anyFamily || (ip.To4() != nil) == want4 {
+			return ip
+		}
+	}
+	return nil
+}
+
+// SetLocalAddr 设置出口源地址（nil 表示恢复由系统选择）。
+// Addrs 可以是 IP 或网卡名，多个地址时按 Rotate 轮换：
+//
+//	c.SetLocalAddr(&client.LocalAddrConfig{Addrs: []string{"10.0.0.2", "10.0.0.3"}})
+//	c.SetLocalAddr(&client.LocalAddrConfig{Addrs: []string{"eth1"}, Rotate: client.LocalAddrRotateSession})
+func (h *HttpClient) SetLocalAddr(cfg *LocalAddrConfig) error {
+	var pool *localAddrPool
+	if cfg != nil {
+		switch cfg.Rotate {
+		case "", LocalAddrRotateConnection, LocalAddrRotateSession:
+		default:
+			return fmt.Errorf("unsupported local addr rotate mode: %s", cfg.Rotate)
+		}
+		ips, err := expandLocalAddrs(cfg.Addrs)
+		if err != nil {
+			return err
+		}
+		if len(ips) > 0 {
+			pool = &localAddrPool{ips: ips, sticky: cfg.Rotate == LocalAddrRotateSession}
+		}
+	}
+	h.mu.Lock()
+	h.localAddrs = pool
+	h.dialGen++
+	h.mu.Unlock()
+	// 已建立的连接仍绑定旧地址，关闭空闲连接使新配置立即生效
+	h.transport.CloseIdleConnections()
+	return nil
+}
+
+// expandLocalAddrs 将 IP/网卡名列表展开为 IP 列表。
+func expandLocalAddrs(addrs []string) ([]net.IP, error) {
+	var ips []net.IP
+	for _, a := range addrs {
+		if ip := net.ParseIP(a); ip != nil {
+			ips = append(ips, ip)
+			continue
+		}
+		iface, err := net.InterfaceByName(a)
+		if err != nil {
+			return nil, fmt.Errorf("invalid local addr %q: %w", a, err)
+		}
+		ifAddrs, err := iface.Addrs()
+		if err != nil {
+			return nil, fmt.Errorf("read addrs of %s failed: %w", a, err)
+		}
+		n := len(ips)
+		for _, ia := range ifAddrs {
+			ipNet, ok := ia.(*net.IPNet)
+			// 链路本地地址需要 zone，不适合作为通用出口地址
+			if !ok || ipNet.IP.IsLinkLocalUnicast() {
+				continue
+			}
+			ips = append(ips, ipNet.IP)
+		}
+		if len(ips) == n {
+			return nil, fmt.Errorf("interface %s has no usable address", a)
+		}
+	}
+	return ips, nil
+}
+
+// connLocalAddr 按 client 级别配置为新连接选择源地址，未配置时返回 nil。
+func (h *HttpClient) connLocalAddr(host string) net.IP {
+	h.mu.RLock()
+	pool := h.localAddrs
+	h.mu.RUnlock()
+	if pool == nil {
+		return nil
+	}
+	return pool.pick(pool.seed(), host)
+}
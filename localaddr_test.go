@@ -0,0 +1,114 @@
+package client
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// remoteIPServer 返回对端源 IP 的测试服务端。
+func remoteIPServer(tls bool) *httptest.Server {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		w.Write([]byte(host))
+	})
+	if tls {
+		return httptest.NewTLSServer(h)
+	}
+	return httptest.NewServer(h)
+}
+
+func TestSetLocalAddr_RotatePerConnection(t *testing.T) {
+	for _, profile := range tlsProfiles {
+		ts := remoteIPServer(profile != "")
+		c := NewHttpClient(ts.URL)
+		if profile != "" {
+			trustServer(t, c, ts, profile)
+		}
+		if err := c.SetLocalAddr(&LocalAddrConfig{Addrs: []string{"127.0.0.2", "::1", "127.0.0.3"}}); err != nil {
+			t.Fatalf("SetLocalAddr failed: %v", err)
+		}
+		var got []string
+		for i := 0; i < 3; i++ {
+			body, err := c.DoGet("/")
+			if err != nil {
+				t.Fatalf("[%s] DoGet failed: %v", profile, err)
+			}
+			got = append(got, string(body))
+			c.Close()
+		}
+		// IPv6 地址与 IPv4 目标不同族，会被跳过
+		if got[0] != "127.0.0.2" || got[1] != "127.0.0.3" || got[2] != "127.0.0.3" {
+			t.Fatalf("[%s] unexpected source addrs: %v", profile, got)
+		}
+		ts.Close()
+	}
+}
+
+func TestSetLocalAddr_StickyPerSession(t *testing.T) {
+	ts := remoteIPServer(false)
+	defer ts.Close()
+
+	c := NewHttpClient(ts.URL)
+	err := c.SetLocalAddr(&LocalAddrConfig{
+		Addrs:  []string{"127.0.0.2", "127.0.0.3"},
+		Rotate: LocalAddrRotateSession,
+	})
+	if err != nil {
+		t.Fatalf("SetLocalAddr failed: %v", err)
+	}
+	for _, want := range []string{"127.0.0.2", "127.0.0.3"} {
+		s := NewSession()
+		for i := 0; i < 2; i++ {
+			body, err := c.DoGetWithSession(s, "/")
+			if err != nil {
+				t.Fatalf("DoGetWithSession failed: %v", err)
+			}
+			if string(body) != want {
+				t.Fatalf("session should stick to %s, got %s", want, body)
+			}
+			s.CloseIdleConnections()
+		}
+	}
+
+	_ = c.SetLocalAddr(nil)
+	if c.transportFor(NewSession()) != c.transport {
+		t.Fatal("sessions should share the client transport after reset")
+	}
+}
+
+func TestSetLocalAddr_Invalid(t *testing.T) {
+	c := NewHttpClient("http://example.com")
+	if err := c.SetLocalAddr(&LocalAddrConfig{Addrs: []string{"no-such-iface0"}}); err == nil {
+		t.Fatal("expected error for unknown interface")
+	}
+	if err := c.SetLocalAddr(&LocalAddrConfig{Addrs: []string{"127.0.0.1"}, Rotate: "random"}); err == nil {
+		t.Fatal("expected error for unsupported rotate mode")
+	}
+	if err := c.SetLocalAddr(&LocalAddrConfig{Addrs: []string{"lo"}}); err != nil {
+		t.Fatalf("interface name should be accepted: %v", err)
+	}
+}
+
+func TestSetLocalAddr_HostnameMatchesFamily(t *testing.T) {
+	ts := remoteIPServer(false)
+	defer ts.Close()
+	_, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+
+	// 目标为主机名时先解析，不能因轮到 IPv6 源地址而拨向只监听 IPv4 的服务
+	c := NewHttpClient("http://localhost:" + port)
+	if err := c.SetLocalAddr(&LocalAddrConfig{Addrs: []string{"::1", "127.0.0.2"}}); err != nil {
+		t.Fatalf("SetLocalAddr failed: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		body, err := c.DoGet("/")
+		if err != nil {
+			t.Fatalf("DoGet failed: %v", err)
+		}
+		if string(body) != "127.0.0.2" {
+			t.Fatalf("expected IPv4 source for IPv4 target, got %s", body)
+		}
+		c.Close()
+	}
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"log/slog"
+
+	"go.uber.org/zap"
+)
+
+// Logger 日志接口，kv 为 key/value 交替的结构化字段（key 为 string），实现需并发安全。
+// *slog.Logger 直接满足该接口；zap 使用 NewZapLogger 适配。
+type Logger interface {
+	Debug(msg string, kv ...interface{})
+	Info(msg string, kv ...interface{})
+	Warn(msg string, kv ...interface{})
+	Error(msg string, kv ...interface{})
+}
+
+// zapLogger 将 *zap.SugaredLogger 适配为 Logger。
+type zapLogger struct {
+	s *zap.SugaredLogger
+}
+
+// NewZapLogger 适配 zap；kv 按 Infow 等方法的约定解析。
+func NewZapLogger(s *zap.SugaredLogger) Logger {
+	return zapLogger{s: s}
+}
+
+func (l zapLogger) Debug(msg string, kv ...interface{}) { l.s.Debugw(msg, kv...) }
+func (l zapLogger) Info(msg string, kv ...interface{})  { l.s.Infow(msg, kv...) }
+func (l zapLogger) Warn(msg string, kv ...interface{})  { l.s.Warnw(msg, kv...) }
+func (l zapLogger) Error(msg string, kv ...interface{}) { l.s.Errorw(msg, kv...) }
+
+// NewSlogLogger 适配 log/slog，l 为 nil 时使用 slog.Default()。
+func NewSlogLogger(l *slog.Logger) Logger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return l
+}
+
+// nopLogger 丢弃全部日志。
+type nopLogger struct{}
+
+// NopLogger 返回丢弃全部日志的 Logger，效果与未设置 logger 相同。
+func NopLogger() Logger {
+	return nopLogger{}
+}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+// SetLogger 设置 zap 日志记录器，nil 表示关闭日志；等价于 UseLogger(NewZapLogger(logger))。
+func (h *HttpClient) SetLogger(logger *zap.SugaredLogger) {
+	if logger == nil {
+		h.UseLogger(nil)
+		return
+	}
+	h.UseLogger(NewZapLogger(logger))
+}
+
+// UseLogger 设置任意 Logger 实现，nil 或 NopLogger() 表示关闭日志。
+func (h *HttpClient) UseLogger(l Logger) {
+	if _, ok := l.(nopLogger); ok {
+		l = nil
+	}
+	h.logger = l
+}
+
+// LogInfo 输出 Info 级别日志。
+func (h *HttpClient) LogInfo(msg string, kv ...interface{}) {
+	if h.logger != nil {
+		h.logger.Info(msg, kv...)
+	}
+}
+
+// LogError 输出 Error 级别日志，err 以 "error" 字段输出，kv 为附加字段。
+func (h *HttpClient) LogError(msg string, err error, kv ...interface{}) {
+	if h.logger != nil {
+		h.logger.Error(msg, append([]interface{}{"error", err}, kv...)...)
+	}
+}
+
+// logAt 按级别输出日志。
+func (h *HttpClient) logAt(level LogLevel, msg string, kv ...interface{}) {
+	if h.logger == nil {
+		return
+	}
+	switch level {
+	case LogLevelDebug:
+		h.logger.Debug(msg, kv...)
+	case LogLevelInfo:
+		h.logger.Info(msg, kv...)
+	case LogLevelWarn:
+		h.logger.Warn(msg, kv...)
+	case LogLevelError:
+		h.logger.Error(msg, kv...)
+	}
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestUseLogger_Slog(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("bad"))
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	c := NewHttpClient(ts.URL)
+	c.UseLogger(NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+	c.DoGet("/x")
+	c.LogError("custom failure", errors.New("boom"), "key", "value")
+
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("invalid slog line %q: %v", line, err)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected send, status and custom entries, got %d: %s", len(entries), buf.String())
+	}
+	if entries[0]["level"] != "DEBUG" || entries[0]["msg"] != "请求准备发送" {
+		t.Fatalf("unexpected send entry: %v", entries[0])
+	}
+	if entries[1]["level"] != "WARN" || entries[1]["status"] != float64(400) || entries[1]["response_body"] != "bad" {
+		t.Fatalf("unexpected status entry: %v", entries[1])
+	}
+	if entries[2]["level"] != "ERROR" || entries[2]["error"] != "boom" || entries[2]["key"] != "value" {
+		t.Fatalf("unexpected custom entry: %v", entries[2])
+	}
+	for _, e := range entries {
+		if _, ok := e["!BADKEY"]; ok {
+			t.Fatalf("entry has malformed key/value pairs: %v", e)
+		}
+	}
+}
+
+func TestUseLogger_ZapAndNop(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	c := NewHttpClient("http://example.com")
+	c.UseLogger(NewZapLogger(zap.New(core).Sugar()))
+	c.LogInfo("info", "k", 1)
+	c.LogError("error", errors.New("boom"))
+	if logs.Len() != 2 || logs.All()[1].ContextMap()["error"] != "boom" {
+		t.Fatalf("unexpected zap entries: %v", logs.All())
+	}
+
+	c.UseLogger(NopLogger())
+	if c.logger != nil {
+		t.Fatal("NopLogger should disable logging")
+	}
+	c.LogInfo("dropped")
+	c.SetLogger(nil)
+	c.LogError("dropped", errors.New("x"))
+	if logs.Len() != 2 {
+		t.Fatalf("logs should be dropped, got %d", logs.Len())
+	}
+}
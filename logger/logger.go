@@ -1,38 +1,189 @@
+// Package logger 提供基于 zap + lumberjack 的日志构造，支持文件轮转、多路输出与运行时调整级别。
+//
+//	l, err := logger.New(logger.WithFile("logs/app.log"), logger.WithEncoding("json"))
+//	c.SetLogger(l.SugaredLogger)
+//	l.SetLevel(zapcore.DebugLevel)
 package logger
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"gopkg.in/natefinch/lumberjack.v2"
-	"os"
-	"strings"
 )
 
-var logger *zap.Logger
-var sugarLogger *zap.SugaredLogger
+// 输出目标。
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+)
 
-func GetLogger() *zap.SugaredLogger {
-	if sugarLogger == nil {
-		logger, _ = zap.NewProduction()
-		sugarLogger = logger.Sugar()
+// Options 日志配置。
+type Options struct {
+	Level      zapcore.Level // 初始级别，可通过 Logger.SetLevel 运行时调整
+	Encoding   string        // "console" 或 "json"
+	Outputs    []string      // OutputStdout / OutputStderr / OutputFile 的组合
+	File       string        // OutputFile 的路径
+	MaxSize    int           // 单个文件最大 MB，超过后轮转
+	MaxBackups int           // 保留的旧文件个数，0 表示不限
+	MaxAge     int           // 旧文件保留天数，0 表示不限
+	Compress   bool          // 是否 gzip 压缩旧文件
+	Caller     bool          // 是否输出调用位置
+}
+
+// Option 修改 Options。
+type Option func(*Options)
+
+// DefaultOptions 默认配置：console 编码，同时输出到 stdout 与 logs/app.log（10MB 轮转，保留 5 个、7 天），
+// 级别取自环境变量 LOG_LEVEL（默认 INFO）。
+func DefaultOptions() Options {
+	return Options{
+		Level:      ParseLevel(os.Getenv("LOG_LEVEL")),
+		Encoding:   "console",
+		Outputs:    []string{OutputFile, OutputStdout},
+		File:       "logs/app.log",
+		MaxSize:    10,
+		MaxBackups: 5,
+		MaxAge:     7,
+		Caller:     true,
+	}
+}
+
+// WithLevel 设置初始级别。
+func WithLevel(level zapcore.Level) Option {
+	return func(o *Options) { o.Level = level }
+}
+
+// WithEncoding 设置编码："console" 或 "json"。
+func WithEncoding(encoding string) Option {
+	return func(o *Options) { o.Encoding = encoding }
+}
+
+// WithOutputs 设置输出目标，如 WithOutputs(OutputStdout)。
+func WithOutputs(outputs ...string) Option {
+	return func(o *Options) { o.Outputs = outputs }
+}
+
+// WithFile 设置日志文件路径。
+func WithFile(path string) Option {
+	return func(o *Options) { o.File = path }
+}
+
+// WithRotation 设置轮转参数：单文件最大 MB、保留个数与保留天数。
+func WithRotation(maxSizeMB, maxBackups, maxAgeDays int) Option {
+	return func(o *Options) {
+		o.MaxSize = maxSizeMB
+		o.MaxBackups = maxBackups
+		o.MaxAge = maxAgeDays
+	}
+}
+
+// WithCompress 设置是否压缩轮转后的旧文件。
+func WithCompress(compress bool) Option {
+	return func(o *Options) { o.Compress = compress }
+}
+
+// WithCaller 设置是否输出调用位置。
+func WithCaller(caller bool) Option {
+	return func(o *Options) { o.Caller = caller }
+}
+
+// Logger zap 日志及其可调级别。
+type Logger struct {
+	*zap.SugaredLogger
+	level  zap.AtomicLevel
+	closer io.Closer // 日志文件，未输出到文件时为 nil
+}
+
+// New 在 DefaultOptions 基础上应用 opts 创建 Logger。
+func New(opts ...Option) (*Logger, error) {
+	o := DefaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return NewWithOptions(o)
+}
+
+// NewWithOptions 按完整配置创建 Logger。
+func NewWithOptions(o Options) (*Logger, error) {
+	encoder, err := newEncoder(o.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	if len(o.Outputs) == 0 {
+		return nil, errors.New("logger: no outputs")
+	}
+	l := &Logger{level: zap.NewAtomicLevelAt(o.Level)}
+	cores := make([]zapcore.Core, 0, len(o.Outputs))
+	for _, out := range o.Outputs {
+		var ws zapcore.WriteSyncer
+		switch out {
+		case OutputStdout:
+			ws = zapcore.Lock(os.Stdout)
+		case OutputStderr:
+			ws = zapcore.Lock(os.Stderr)
+		case OutputFile:
+			if o.File == "" {
+				return nil, errors.New("logger: file output requires a path")
+			}
+			if l.closer != nil {
+				return nil, errors.New("logger: duplicate file output")
+			}
+			lj := &lumberjack.Logger{
+				Filename:   o.File,
+				MaxSize:    o.MaxSize,
+				MaxBackups: o.MaxBackups,
+				MaxAge:     o.MaxAge,
+				Compress:   o.Compress,
+			}
+			l.closer = lj
+			ws = zapcore.AddSync(lj)
+		default:
+			return nil, fmt.Errorf("logger: unknown output %q", out)
+		}
+		cores = append(cores, zapcore.NewCore(encoder, ws, l.level))
+	}
+	var zopts []zap.Option
+	if o.Caller {
+		zopts = append(zopts, zap.AddCaller())
 	}
-	logLevel := zap.NewAtomicLevelAt(getLogLevel(os.Getenv("LOG_LEVEL")))
-	encoder := getEncoder()
-	writerSyncer := getLogWriter()
-	fileCore := zapcore.NewCore(encoder, writerSyncer, logLevel)
-	consoleOutput := zapcore.Lock(os.Stdout)
-	consoleCore := zapcore.NewCore(
-		encoder,
-		consoleOutput,
-		logLevel,
-	)
-	core := zapcore.NewTee(fileCore, consoleCore)
-	logger = zap.New(core, zap.AddCaller())
-	sugarLogger = logger.Sugar()
-	return sugarLogger
-}
-
-func getEncoder() zapcore.Encoder {
+	l.SugaredLogger = zap.New(zapcore.NewTee(cores...), zopts...).Sugar()
+	return l, nil
+}
+
+// SetLevel 运行时调整级别，并发安全。
+func (l *Logger) SetLevel(level zapcore.Level) {
+	l.level.SetLevel(level)
+}
+
+// Level 返回当前级别。
+func (l *Logger) Level() zapcore.Level {
+	return l.level.Level()
+}
+
+// LevelHandler 返回查看/修改级别的 HTTP 接口（GET 查询，PUT {"level":"debug"} 修改）。
+func (l *Logger) LevelHandler() http.Handler {
+	return l.level
+}
+
+// Close 刷新缓冲并关闭日志文件。
+func (l *Logger) Close() error {
+	_ = l.Sync()
+	if l.closer != nil {
+		return l.closer.Close()
+	}
+	return nil
+}
+
+func newEncoder(encoding string) (zapcore.Encoder, error) {
 	encoderConfig := zapcore.EncoderConfig{
 		TimeKey:        "time",
 		LevelKey:       "level",
@@ -45,21 +196,18 @@ func getEncoder() zapcore.Encoder {
 		EncodeCaller:   zapcore.ShortCallerEncoder,
 		EncodeLevel:    zapcore.CapitalLevelEncoder,
 	}
-	return zapcore.NewConsoleEncoder(encoderConfig)
-}
-
-func getLogWriter() zapcore.WriteSyncer {
-	lumberJackLogger := &lumberjack.Logger{
-		Filename:   "logs/app.log",
-		MaxSize:    10,
-		MaxBackups: 5,
-		MaxAge:     7,
-		Compress:   false,
+	switch encoding {
+	case "", "console":
+		return zapcore.NewConsoleEncoder(encoderConfig), nil
+	case "json":
+		return zapcore.NewJSONEncoder(encoderConfig), nil
+	default:
+		return nil, fmt.Errorf("logger: unknown encoding %q", encoding)
 	}
-	return zapcore.AddSync(lumberJackLogger)
 }
 
-func getLogLevel(level string) zapcore.Level {
+// ParseLevel 解析 DEBUG/INFO/WARN/ERROR（不区分大小写），无法识别时返回 InfoLevel。
+func ParseLevel(level string) zapcore.Level {
 	switch strings.ToUpper(level) {
 	case "DEBUG":
 		return zapcore.DebugLevel
@@ -71,3 +219,47 @@ func getLogLevel(level string) zapcore.Level {
 		return zapcore.InfoLevel
 	}
 }
+
+var (
+	defaultOnce sync.Once
+	defaultMu   sync.Mutex
+	defaultOpts []Option
+	defaultLog  *Logger
+)
+
+// Init 设置全局 Logger 的配置，须在首次 GetLogger/Default 之前调用，之后调用返回错误。
+func Init(opts ...Option) error {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultLog != nil {
+		return errors.New("logger: already initialized")
+	}
+	defaultOpts = opts
+	return nil
+}
+
+// Default 返回全局 Logger，首次调用时按 Init 的配置创建，并发安全。
+// 配置无效时退回到只输出 stdout 的 console 日志。
+func Default() *Logger {
+	defaultOnce.Do(func() {
+		defaultMu.Lock()
+		defer defaultMu.Unlock()
+		l, err := New(defaultOpts...)
+		if err != nil {
+			l, _ = New(WithOutputs(OutputStdout), WithEncoding("console"))
+			l.Warnw("logger: invalid options, falling back to stdout", "error", err)
+		}
+		defaultLog = l
+	})
+	return defaultLog
+}
+
+// GetLogger 返回全局 SugaredLogger，见 Default。
+func GetLogger() *zap.SugaredLogger {
+	return Default().SugaredLogger
+}
+
+// SetLevel 运行时调整全局 Logger 的级别。
+func SetLevel(level zapcore.Level) {
+	Default().SetLevel(level)
+}
@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNew_JSONFileAndLevel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := New(
+		WithFile(path),
+		WithOutputs(OutputFile),
+		WithEncoding("json"),
+		WithLevel(zapcore.WarnLevel),
+		WithRotation(1, 2, 3),
+		WithCompress(true),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	l.Infow("dropped")
+	l.Warnw("kept", "k", "v")
+	l.SetLevel(zapcore.DebugLevel)
+	l.Debugw("debug after change")
+	if l.Level() != zapcore.DebugLevel {
+		t.Fatalf("unexpected level: %v", l.Level())
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d:\n%s", len(lines), data)
+	}
+	var e map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &e); err != nil {
+		t.Fatalf("not json: %v", err)
+	}
+	if e["msg"] != "kept" || e["k"] != "v" || e["level"] != "WARN" {
+		t.Fatalf("unexpected entry: %v", e)
+	}
+}
+
+func TestNew_InvalidOptions(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"encoding": {WithEncoding("xml")},
+		"output":   {WithOutputs("syslog")},
+		"empty":    {WithOutputs()},
+		"no file":  {WithOutputs(OutputFile), WithFile("")},
+	} {
+		if _, err := New(opts...); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestDefault_OnceAndConcurrent(t *testing.T) {
+	if err := Init(WithOutputs(OutputStderr), WithLevel(zapcore.ErrorLevel)); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	var wg sync.WaitGroup
+	results := make([]*Logger, 16)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = Default()
+			GetLogger().Debugw("concurrent")
+		}(i)
+	}
+	wg.Wait()
+	for _, l := range results {
+		if l != results[0] {
+			t.Fatal("Default should return a single instance")
+		}
+	}
+	if results[0].Level() != zapcore.ErrorLevel {
+		t.Fatalf("Init options not applied: %v", results[0].Level())
+	}
+	SetLevel(zapcore.WarnLevel)
+	if Default().Level() != zapcore.WarnLevel {
+		t.Fatal("SetLevel should change the global level")
+	}
+	if err := Init(); err == nil {
+		t.Fatal("Init after initialization should fail")
+	}
+}
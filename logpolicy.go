@@ -0,0 +1,239 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand/v2"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// LogLevel 日志级别，取值与 zapcore.Level 一致。
+type LogLevel int8
+
+const (
+	LogLevelDebug LogLevel = iota - 1
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+	LogLevelOff // 不输出
+)
+
+// LogEvent 请求生命周期中的日志事件。
+type LogEvent string
+
+const (
+	LogEventSend    LogEvent = "send"    // 请求准备发送，默认 Debug
+	LogEventRetry   LogEvent = "retry"   // 瞬态错误后重试，默认 Warn
+	LogEventSuccess LogEvent = "success" // 2xx 响应，默认 Info
+	LogEventStatus  LogEvent = "status"  // 非 2xx 响应，默认 Warn
+	LogEventFailure LogEvent = "failure" // 传输错误、响应读取或解压失败，默认 Error
+)
+
+// DefaultLogMaxBodyBytes 日志中 body 默认最多输出的字节数。
+const DefaultLogMaxBodyBytes = 4096
+
+// defaultLogLevels 各事件的默认级别。
+var defaultLogLevels = map[LogEvent]LogLevel{
+	LogEventSend:    LogLevelDebug,
+	LogEventRetry:   LogLevelWarn,
+	LogEventSuccess: LogLevelInfo,
+	LogEventStatus:  LogLevelWarn,
+	LogEventFailure: LogLevelError,
+}
+
+// LogPolicy 请求日志策略。
+type LogPolicy struct {
+	Levels       map[LogEvent]LogLevel // 覆盖事件级别，未列出的使用默认值
+	MaxBodyBytes int                   // body 最多输出的字节数，0 使用 DefaultLogMaxBodyBytes，<0 不输出 body
+	LogBinary    bool                  // 是否输出非文本 body（图片、压缩包等），默认只输出类型与长度
+	// SampleRates 按主机名对 send/success 事件采样，取值 0~1；"*" 为默认值，未配置时全部输出。
+	// 重试、非 2xx 与失败事件不采样。
+	SampleRates map[string]float64
+	// FailuresOnly 调试模式：只输出 retry/status/failure 事件，且 body 不截断。
+	FailuresOnly bool
+}
+
+// DefaultLogPolicy 返回默认日志策略。
+func DefaultLogPolicy() *LogPolicy {
+	return &LogPolicy{}
+}
+
+// SetLogPolicy 设置请求日志策略，nil 恢复默认。
+func (h *HttpClient) SetLogPolicy(p *LogPolicy) {
+	if p == nil {
+		p = DefaultLogPolicy()
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.logPolicy = p
+}
+
+// getLogPolicy 返回当前日志策略。
+func (h *HttpClient) getLogPolicy() *LogPolicy {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.logPolicy == nil {
+		return DefaultLogPolicy()
+	}
+	return h.logPolicy
+}
+
+// level 返回事件的日志级别。
+func (p *LogPolicy) level(ev LogEvent) LogLevel {
+	if lv, ok := p.Levels[ev]; ok {
+		return lv
+	}
+	return defaultLogLevels[ev]
+}
+
+// sample 按主机名决定本次请求的 send/success 事件是否输出。
+func (p *LogPolicy) sample(host string) bool {
+	rate, ok := p.SampleRates[host]
+	if !ok {
+		if rate, ok = p.SampleRates["*"]; !ok {
+			return true
+		}
+	}
+	switch {
+	case rate >= 1:
+		return true
+	case rate <= 0:
+		return false
+	default:
+		return rand.Float64() < rate
+	}
+}
+
+// requestLog 一次请求的日志上下文：策略、采样结果与脱敏规则。
+type requestLog struct {
+	h       *HttpClient
+	policy  *LogPolicy
+	rd      *redactor
+	sampled bool
+	id      string // 关联 ID，非空时附加到每条日志
+}
+
+// newRequestLog 为 req 创建日志上下文，采样在此一次性决定。
+func (h *HttpClient) newRequestLog(req *http.Request) *requestLog {
+	p := h.getLogPolicy()
+	return &requestLog{
+		h:       h,
+		policy:  p,
+		rd:      h.getRedactor(),
+		sampled: p.sample(req.URL.Hostname()),
+		id:      RequestIDFromContext(req.Context()),
+	}
+}
+
+// enabled 判断事件是否会输出，用于跳过字段的构造。
+func (l *requestLog) enabled(ev LogEvent) bool {
+	if l.h.logger == nil || l.policy.level(ev) >= LogLevelOff {
+		return false
+	}
+	switch ev {
+	case LogEventSend, LogEventSuccess:
+		return l.sampled && !l.policy.FailuresOnly
+	}
+	return true
+}
+
+// log 按事件级别输出日志，调用方应先用 enabled 判断。
+func (l *requestLog) log(ev LogEvent, msg string, fields ...interface{}) {
+	if l.id != "" {
+		fields = append([]interface{}{"request_id", l.id}, fields...)
+	}
+	l.h.logAt(l.policy.level(ev), msg, fields...)
+}
+
+// body 返回用于日志的 body：超长截断，非文本只输出类型与长度，最后脱敏。
+func (l *requestLog) body(b []byte, contentType string) string {
+	if len(b) == 0 {
+		return ""
+	}
+	limit := l.policy.MaxBodyBytes
+	if limit < 0 {
+		return fmt.Sprintf("[%d bytes omitted]", len(b))
+	}
+	if limit == 0 {
+		limit = DefaultLogMaxBodyBytes
+	}
+	total := len(b)
+	if total > limit && !l.policy.FailuresOnly {
+		b = trimPartialRune(b[:limit])
+	}
+	// 只检查要输出的部分：multipart 中文本字段在前、文件在后时仍可输出字段
+	if !l.policy.LogBinary && !isTextBody(b, contentType) {
+		if contentType == "" {
+			return fmt.Sprintf("[binary, %d bytes]", total)
+		}
+		return fmt.Sprintf("[binary %s, %d bytes]", contentType, total)
+	}
+	s := l.rd.body(string(b))
+	if len(b) < total {
+		s += fmt.Sprintf("...[truncated, %d bytes total]", total)
+	}
+	return s
+}
+
+// curlBodyNote 返回 body 在日志中不会完整输出的原因（被省略、截断或为二进制），完整输出时返回空串。
+func (l *requestLog) curlBodyNote(b []byte, contentType string) string {
+	if len(b) == 0 {
+		return ""
+	}
+	limit := l.policy.MaxBodyBytes
+	if limit < 0 {
+		return fmt.Sprintf("body omitted, %d bytes", len(b))
+	}
+	if limit == 0 {
+		limit = DefaultLogMaxBodyBytes
+	}
+	if len(b) > limit && !l.policy.FailuresOnly {
+		return fmt.Sprintf("body omitted, %d bytes exceeds log limit", len(b))
+	}
+	if !l.policy.LogBinary && !isTextBody(b, contentType) {
+		return fmt.Sprintf("binary body omitted, %d bytes", len(b))
+	}
+	return ""
+}
+
+// trimPartialRune 去掉截断后末尾不完整的 UTF-8 字符。
+func trimPartialRune(b []byte) []byte {
+	for i := 0; i < utf8.UTFMax-1 && len(b) > 0; i++ {
+		if r, size := utf8.DecodeLastRune(b); r != utf8.RuneError || size > 1 {
+			break
+		}
+		b = b[:len(b)-1]
+	}
+	return b
+}
+
+// isTextBody 根据 Content-Type 与内容判断 body 是否可作为文本输出。
+func isTextBody(b []byte, contentType string) bool {
+	if contentType != "" {
+		mt, _, err := mime.ParseMediaType(contentType)
+		if err == nil && !isTextMediaType(mt) {
+			return false
+		}
+	}
+	return bytes.IndexByte(b, 0) < 0 && utf8.Valid(b)
+}
+
+func isTextMediaType(mt string) bool {
+	switch {
+	case strings.HasPrefix(mt, "text/"),
+		strings.HasPrefix(mt, "multipart/"),
+		strings.HasSuffix(mt, "+json"),
+		strings.HasSuffix(mt, "+xml"):
+		return true
+	}
+	switch mt {
+	case "application/json", "application/xml", "application/javascript",
+		"application/x-www-form-urlencoded", "application/graphql",
+		"application/x-ndjson":
+		return true
+	}
+	return false
+}
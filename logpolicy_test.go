@@ -0,0 +1,131 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newLogPolicyServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/big":
+			w.Write([]byte(strings.Repeat("a", 10000)))
+		case "/png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("\x89PNG\r\n\x1a\n\x00\x00"))
+		case "/fail":
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(strings.Repeat("e", 5000)))
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+}
+
+func TestLogPolicy_DefaultLevels(t *testing.T) {
+	ts := newLogPolicyServer()
+	defer ts.Close()
+
+	core, logs := observer.New(zap.DebugLevel)
+	c := NewHttpClient(ts.URL)
+	c.SetLogger(zap.New(core).Sugar())
+	c.DoGet("/ok")
+	c.DoGet("/fail")
+	NewHttpClient("http://127.0.0.1:1").DoGet("/") // 无 logger 不应 panic
+	c.SetDomain("http://127.0.0.1:1")
+	c.DoGet("/")
+
+	for msg, want := range map[string]zapcore.Level{
+		"请求准备发送":    zap.DebugLevel,
+		"请求成功":      zap.InfoLevel,
+		"请求返回非成功状态": zap.WarnLevel,
+		"请求失败":      zap.ErrorLevel,
+	} {
+		entries := logs.FilterMessage(msg).All()
+		if len(entries) == 0 {
+			t.Fatalf("missing %q log", msg)
+		}
+		if entries[0].Level != want {
+			t.Errorf("%q logged at %v, want %v", msg, entries[0].Level, want)
+		}
+	}
+}
+
+func TestLogPolicy_BodyTruncationAndBinary(t *testing.T) {
+	ts := newLogPolicyServer()
+	defer ts.Close()
+
+	core, logs := observer.New(zap.InfoLevel)
+	c := NewHttpClient(ts.URL)
+	c.SetLogger(zap.New(core).Sugar())
+	c.SetLogPolicy(&LogPolicy{MaxBodyBytes: 100})
+	c.DoGet("/big")
+	c.DoGet("/png")
+
+	entries := logs.FilterMessage("请求成功").All()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 success logs, got %d", len(entries))
+	}
+	big := entries[0].ContextMap()["response_body"].(string)
+	if !strings.HasPrefix(big, strings.Repeat("a", 100)+"...[truncated, 10000 bytes total]") {
+		t.Fatalf("unexpected truncated body: %q", big)
+	}
+	if got := entries[1].ContextMap()["response_body"]; got != "[binary image/png, 10 bytes]" {
+		t.Fatalf("unexpected binary body: %q", got)
+	}
+
+	// 截断不落在多字节字符中间
+	l := &requestLog{policy: &LogPolicy{MaxBodyBytes: 4}, rd: newRedactor(nil)}
+	if got := l.body([]byte("中文内容"), "text/plain; charset=utf-8"); got != "中...[truncated, 12 bytes total]" {
+		t.Fatalf("unexpected utf-8 truncation: %q", got)
+	}
+	if got := l.body([]byte{0xff, 0xfe, 0x00, 0x01}, ""); got != "[binary, 4 bytes]" {
+		t.Fatalf("unexpected sniffed binary: %q", got)
+	}
+}
+
+func TestLogPolicy_SamplingAndFailuresOnly(t *testing.T) {
+	ts := newLogPolicyServer()
+	defer ts.Close()
+
+	core, logs := observer.New(zap.DebugLevel)
+	c := NewHttpClient(ts.URL)
+	c.SetLogger(zap.New(core).Sugar())
+	c.SetLogPolicy(&LogPolicy{SampleRates: map[string]float64{"127.0.0.1": 0, "*": 1}})
+	c.DoGet("/ok")
+	c.DoGet("/fail")
+	if n := logs.FilterMessage("请求成功").Len() + logs.FilterMessage("请求准备发送").Len(); n != 0 {
+		t.Fatalf("sampled-out host should not log send/success, got %d", n)
+	}
+	if logs.FilterMessage("请求返回非成功状态").Len() != 1 {
+		t.Fatal("non-2xx responses should never be sampled out")
+	}
+
+	logs.TakeAll()
+	c.SetLogPolicy(&LogPolicy{FailuresOnly: true, MaxBodyBytes: 100})
+	c.DoGet("/ok")
+	c.DoGet("/fail")
+	if logs.Len() != 1 {
+		t.Fatalf("expected only the failure to be logged, got %d entries", logs.Len())
+	}
+	if body := logs.All()[0].ContextMap()["response_body"].(string); len(body) != 5000 {
+		t.Fatalf("failures should be logged in full, got %d bytes", len(body))
+	}
+
+	logs.TakeAll()
+	c.SetLogPolicy(&LogPolicy{Levels: map[LogEvent]LogLevel{LogEventStatus: LogLevelOff, LogEventSuccess: LogLevelDebug}})
+	c.DoGet("/ok")
+	c.DoGet("/fail")
+	if logs.FilterMessage("请求返回非成功状态").Len() != 0 {
+		t.Fatal("status event should be disabled")
+	}
+	if e := logs.FilterMessage("请求成功").All(); len(e) != 1 || e[0].Level != zap.DebugLevel {
+		t.Fatalf("success should be logged at debug: %v", e)
+	}
+}
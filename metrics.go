@@ -0,0 +1,44 @@
+package client
+
+import "time"
+
+// Metrics 请求指标采集接口，由 send 在请求生命周期各节点回调，实现需并发安全。
+// 内置 PrometheusMetrics 实现，也可对接其它监控系统。
+type Metrics interface {
+	// QueueChanged 等待并发槽（MaxConcurrency）的请求数变化，delta 为 +1/-1。
+	QueueChanged(delta int)
+	// QueueWait 一次请求在并发限速处的排队时长。
+	QueueWait(d time.Duration)
+	// InFlight 正在执行的请求数变化，delta 为 +1/-1。
+	InFlight(delta int)
+	// Retry 发生一次重试。
+	Retry(host, method string)
+	// Observe 一次逻辑请求（含重试）结束。
+	Observe(m RequestMetric)
+}
+
+// RequestMetric 一次逻辑请求的结果。
+type RequestMetric struct {
+	RequestID string // 关联 ID，不宜作为指标标签（基数过高）
+	Host      string
+	Method    string
+	Status    int   // 0 表示未收到响应
+	Err       error // 请求失败时的错误
+	Duration  time.Duration
+	BytesOut  int64 // 请求体字节数
+	BytesIn   int64 // 响应体字节数（解压后）
+}
+
+// SetMetrics 设置指标采集器，nil 表示关闭。
+func (h *HttpClient) SetMetrics(m Metrics) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.metrics = m
+}
+
+// getMetrics 返回当前指标采集器。
+func (h *HttpClient) getMetrics() Metrics {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.metrics
+}
@@ -0,0 +1,238 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets 请求耗时直方图的默认桶（秒）。
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// PrometheusMetrics 以 Prometheus 文本格式导出指标的 Metrics 实现，同时是一个 http.Handler：
+//
+//	m := client.NewPrometheusMetrics("")
+//	c.SetMetrics(m)
+//	http.Handle("/metrics", m)
+type PrometheusMetrics struct {
+	namespace string
+	buckets   []float64
+
+	mu          sync.Mutex
+	requests    map[[3]string]float64 // host, method, status
+	retries     map[[2]string]float64 // host, method
+	bytesOut    map[string]float64    // host
+	bytesIn     map[string]float64    // host
+	latency     map[[2]string]*histogram
+	queueWait   *histogram
+	inFlight    float64
+	queueLength float64
+}
+
+// histogram 累积直方图。
+type histogram struct {
+	counts []uint64 // 与 buckets 一一对应，非累积
+	sum    float64
+	count  uint64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{counts: make([]uint64, len(buckets))}
+}
+
+func (hg *histogram) observe(buckets []float64, v float64) {
+	for i, b := range buckets {
+		if v <= b {
+			hg.counts[i]++
+			break
+		}
+	}
+	hg.sum += v
+	hg.count++
+}
+
+// NewPrometheusMetrics 创建 Prometheus 导出器；namespace 为指标名前缀，空时使用 "http_client"。
+// 可选 buckets 覆盖耗时直方图的桶（秒，升序）。
+func NewPrometheusMetrics(namespace string, buckets ...float64) *PrometheusMetrics {
+	if namespace == "" {
+		namespace = "http_client"
+	}
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &PrometheusMetrics{
+		namespace: namespace,
+		buckets:   buckets,
+		requests:  make(map[[3]string]float64),
+		retries:   make(map[[2]string]float64),
+		bytesOut:  make(map[string]float64),
+		bytesIn:   make(map[string]float64),
+		latency:   make(map[[2]string]*histogram),
+		queueWait: newHistogram(buckets),
+	}
+}
+
+// QueueChanged 实现 Metrics。
+func (p *PrometheusMetrics) QueueChanged(delta int) {
+	p.mu.Lock()
+	p.queueLength += float64(delta)
+	p.mu.Unlock()
+}
+
+// QueueWait 实现 Metrics。
+func (p *PrometheusMetrics) QueueWait(d time.Duration) {
+	p.mu.Lock()
+	p.queueWait.observe(p.buckets, d.Seconds())
+	p.mu.Unlock()
+}
+
+// InFlight 实现 Metrics。
+func (p *PrometheusMetrics) InFlight(delta int) {
+	p.mu.Lock()
+	p.inFlight += float64(delta)
+	p.mu.Unlock()
+}
+
+// Retry 实现 Metrics。
+func (p *PrometheusMetrics) Retry(host, method string) {
+	p.mu.Lock()
+	p.retries[[2]string{host, method}]++
+	p.mu.Unlock()
+}
+
+// Observe 实现 Metrics。失败请求的 status 标签为 "error"。
+func (p *PrometheusMetrics) Observe(m RequestMetric) {
+	status := "error"
+	if m.Status > 0 {
+		status = strconv.Itoa(m.Status)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests[[3]string{m.Host, m.Method, status}]++
+	key := [2]string{m.Host, m.Method}
+	hg, ok := p.latency[key]
+	if !ok {
+		hg = newHistogram(p.buckets)
+		p.latency[key] = hg
+	}
+	hg.observe(p.buckets, m.Duration.Seconds())
+	p.bytesOut[m.Host] += float64(m.BytesOut)
+	p.bytesIn[m.Host] += float64(m.BytesIn)
+}
+
+// ServeHTTP 以 Prometheus 文本格式（0.0.4）输出全部指标。
+func (p *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	p.WriteTo(w)
+}
+
+// WriteTo 将全部指标以 Prometheus 文本格式写入 w。
+func (p *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	p.mu.Lock()
+	ns := p.namespace
+
+	writeHeader(&b, ns+"_requests_total", "counter", "Total requests by host, method and status.")
+	for _, k := range sortedKeys(p.requests) {
+		writeSample(&b, ns+"_requests_total", labels("host", k[0], "method", k[1], "status", k[2]), p.requests[k])
+	}
+
+	writeHeader(&b, ns+"_request_duration_seconds", "histogram", "Request latency including retries.")
+	for _, k := range sortedKeys(p.latency) {
+		p.writeHistogram(&b, ns+"_request_duration_seconds", labels("host", k[0], "method", k[1]), p.latency[k])
+	}
+
+	writeHeader(&b, ns+"_retries_total", "counter", "Total retry attempts by host and method.")
+	for _, k := range sortedKeys(p.retries) {
+		writeSample(&b, ns+"_retries_total", labels("host", k[0], "method", k[1]), p.retries[k])
+	}
+
+	writeHeader(&b, ns+"_requests_in_flight", "gauge", "Requests currently being executed.")
+	writeSample(&b, ns+"_requests_in_flight", "", p.inFlight)
+
+	writeHeader(&b, ns+"_semaphore_queue_length", "gauge", "Requests waiting for a concurrency slot.")
+	writeSample(&b, ns+"_semaphore_queue_length", "", p.queueLength)
+
+	writeHeader(&b, ns+"_semaphore_wait_seconds", "histogram", "Time spent waiting for a concurrency slot.")
+	p.writeHistogram(&b, ns+"_semaphore_wait_seconds", "", p.queueWait)
+
+	writeHeader(&b, ns+"_request_bytes_total", "counter", "Request body bytes sent by host.")
+	for _, k := range sortedKeys(p.bytesOut) {
+		writeSample(&b, ns+"_request_bytes_total", labels("host", k), p.bytesOut[k])
+	}
+
+	writeHeader(&b, ns+"_response_bytes_total", "counter", "Response body bytes received by host.")
+	for _, k := range sortedKeys(p.bytesIn) {
+		writeSample(&b, ns+"_response_bytes_total", labels("host", k), p.bytesIn[k])
+	}
+	p.mu.Unlock()
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// writeHistogram 输出直方图的 _bucket/_sum/_count 样本。
+func (p *PrometheusMetrics) writeHistogram(b *strings.Builder, name, lbls string, hg *histogram) {
+	var cum uint64
+	for i, bound := range p.buckets {
+		cum += hg.counts[i]
+		writeSample(b, name+"_bucket", joinLabels(lbls, labels("le", formatFloat(bound))), float64(cum))
+	}
+	writeSample(b, name+"_bucket", joinLabels(lbls, labels("le", "+Inf")), float64(hg.count))
+	writeSample(b, name+"_sum", lbls, hg.sum)
+	writeSample(b, name+"_count", lbls, float64(hg.count))
+}
+
+func writeHeader(b *strings.Builder, name, typ, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func writeSample(b *strings.Builder, name, lbls string, v float64) {
+	if lbls != "" {
+		lbls = "{" + lbls + "}"
+	}
+	fmt.Fprintf(b, "%s%s %s\n", name, lbls, formatFloat(v))
+}
+
+// labels 将 k/v 对渲染为 Prometheus 标签（不含花括号）。
+func labels(kv ...string) string {
+	parts := make([]string, 0, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		parts = append(parts, kv[i]+`="`+escapeLabel(kv[i+1])+`"`)
+	}
+	return strings.Join(parts, ",")
+}
+
+func joinLabels(a, b string) string {
+	if a == "" {
+		return b
+	}
+	return a + "," + b
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// sortedKeys 返回按字典序排列的 map key，保证输出稳定。
+func sortedKeys[K [2]string | [3]string | string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+	return keys
+}
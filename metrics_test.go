@@ -0,0 +1,112 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPrometheusMetrics_Requests(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fail":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/drop":
+			// 直接断开连接，触发 EOF 重试
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+	defer ts.Close()
+	host := strings.TrimPrefix(ts.URL, "http://")
+
+	m := NewPrometheusMetrics("")
+	c := NewHttpClient(ts.URL)
+	c.SetMetrics(m)
+	if _, err := c.DoPost("/ok", map[string]string{"a": "b"}); err != nil {
+		t.Fatalf("DoPost failed: %v", err)
+	}
+	_, _ = c.DoGet("/fail")
+	if _, err := c.DoGet("/drop"); err == nil {
+		t.Fatal("expected error for dropped connection")
+	}
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	out := rec.Body.String()
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Fatalf("unexpected content type: %s", rec.Header().Get("Content-Type"))
+	}
+	for _, want := range []string{
+		`http_client_requests_total{host="` + host + `",method="POST",status="200"} 1`,
+		`http_client_requests_total{host="` + host + `",method="GET",status="500"} 1`,
+		`http_client_requests_total{host="` + host + `",method="GET",status="error"} 1`,
+		`http_client_retries_total{host="` + host + `",method="GET"} 3`,
+		`http_client_request_duration_seconds_count{host="` + host + `",method="GET"} 2`,
+		`http_client_request_duration_seconds_bucket{host="` + host + `",method="POST",le="+Inf"} 1`,
+		`http_client_request_bytes_total{host="` + host + `"} 3`,
+		`http_client_response_bytes_total{host="` + host + `"} 10`,
+		`http_client_requests_in_flight 0`,
+		"# TYPE http_client_request_duration_seconds histogram",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics output missing %q\n%s", want, out)
+		}
+	}
+}
+
+func TestPrometheusMetrics_Semaphore(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	m := NewPrometheusMetrics("scraper", 0.01, 1)
+	c := NewHttpClientWithTransport(ts.URL, &TransportConfig{MaxConcurrency: 1})
+	c.SetMetrics(m)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.DoGet("/")
+		}()
+	}
+	// 等待一个请求占用并发槽、两个请求排队
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var b strings.Builder
+		m.WriteTo(&b)
+		if strings.Contains(b.String(), "scraper_semaphore_queue_length 2") &&
+			strings.Contains(b.String(), "scraper_requests_in_flight 1") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 2 queued and 1 in flight:\n%s", b.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	var b strings.Builder
+	m.WriteTo(&b)
+	for _, want := range []string{
+		"scraper_semaphore_queue_length 0",
+		"scraper_semaphore_wait_seconds_count 3",
+		`scraper_semaphore_wait_seconds_bucket{le="1"} 3`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("metrics output missing %q\n%s", want, b.String())
+		}
+	}
+}
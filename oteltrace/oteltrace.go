@@ -0,0 +1,126 @@
+// Package oteltrace 将 OpenTelemetry 适配为 client.Tracer：
+//
+//	c.SetTracer(oteltrace.New(otel.GetTracerProvider()))
+//
+// span 以调用方 ctx 中的 OpenTelemetry span 为父；ctx 中只有 client.ContextWithSpanContext
+// 设置的远端上下文时以其为父，traceparent/tracestate 由 client 按 W3C 格式注入。
+package oteltrace
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	client "github.com/szwtdl/req"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName OpenTelemetry instrumentation scope 名称。
+const instrumentationName = "github.com/szwtdl/req"
+
+// Tracer 基于 trace.Tracer 的 client.Tracer 实现。
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// New 创建适配器，tp 为 nil 时使用全局 TracerProvider。
+func New(tp trace.TracerProvider) *Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return &Tracer{tracer: tp.Tracer(instrumentationName)}
+}
+
+// Start 实现 client.Tracer。
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, client.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		if sc, ok := client.SpanContextFromContext(ctx); ok {
+			ctx = trace.ContextWithRemoteSpanContext(ctx, toOTel(sc))
+		}
+	}
+	ctx, s := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+	return ctx, &span{span: s}
+}
+
+// span 包装 trace.Span，只在发起请求的 goroutine 中使用。
+type span struct {
+	span   trace.Span
+	failed bool // 设置过 error.type（HTTP 4xx/5xx）
+}
+
+// SetAttributes 实现 client.Span。
+func (s *span) SetAttributes(kv ...interface{}) {
+	attrs := make([]attribute.KeyValue, 0, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		key, ok := kv[i].(string)
+		if !ok {
+			continue
+		}
+		if key == client.AttrErrorType {
+			s.failed = true
+		}
+		attrs = append(attrs, toAttribute(key, kv[i+1]))
+	}
+	s.span.SetAttributes(attrs...)
+}
+
+// SpanContext 实现 client.Span。
+func (s *span) SpanContext() client.SpanContext {
+	sc := s.span.SpanContext()
+	return client.SpanContext{
+		TraceID:    sc.TraceID(),
+		SpanID:     sc.SpanID(),
+		Flags:      byte(sc.TraceFlags()),
+		TraceState: sc.TraceState().String(),
+	}
+}
+
+// End 实现 client.Span，失败时记录错误并将状态置为 Error。
+func (s *span) End(err error) {
+	switch {
+	case err != nil:
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	case s.failed:
+		s.span.SetStatus(codes.Error, "")
+	}
+	s.span.End()
+}
+
+// toOTel 将 client.SpanContext 转为远端 trace.SpanContext。
+func toOTel(sc client.SpanContext) trace.SpanContext {
+	cfg := trace.SpanContextConfig{
+		TraceID:    sc.TraceID,
+		SpanID:     sc.SpanID,
+		TraceFlags: trace.TraceFlags(sc.Flags),
+		Remote:     true,
+	}
+	if sc.TraceState != "" {
+		if ts, err := trace.ParseTraceState(sc.TraceState); err == nil {
+			cfg.TraceState = ts
+		}
+	}
+	return trace.NewSpanContext(cfg)
+}
+
+func toAttribute(key string, v interface{}) attribute.KeyValue {
+	switch v := v.(type) {
+	case string:
+		return attribute.String(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	case time.Duration:
+		return attribute.String(key, v.String())
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,92 @@
+package oteltrace
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	client "github.com/szwtdl/req"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracer_OpenTelemetry(t *testing.T) {
+	var traceparent string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	defer tp.Shutdown(context.Background())
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "handler")
+	c := client.NewHttpClient(ts.URL)
+	c.SetTracer(New(tp))
+	if _, err := c.Do(&client.Request{Context: ctx, Path: "/"}); err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	parent.End()
+
+	spans := exp.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+	attempt, request := spans[0], spans[1]
+	if request.Name != "HTTP GET" || attempt.Name != "HTTP GET attempt" {
+		t.Fatalf("unexpected span names: %q %q", request.Name, attempt.Name)
+	}
+	if request.Parent.SpanID() != parent.SpanContext().SpanID() || attempt.Parent.SpanID() != request.SpanContext.SpanID() {
+		t.Fatal("unexpected span hierarchy")
+	}
+	if request.SpanKind != trace.SpanKindClient || request.Status.Code != codes.Error {
+		t.Fatalf("unexpected request span kind/status: %v %v", request.SpanKind, request.Status)
+	}
+	var status int64
+	for _, kv := range request.Attributes {
+		if kv.Key == client.AttrHTTPStatusCode {
+			status = kv.Value.AsInt64()
+		}
+	}
+	if status != http.StatusBadGateway {
+		t.Fatalf("unexpected status attribute: %d", status)
+	}
+
+	want := "00-" + attempt.SpanContext.TraceID().String() + "-" + attempt.SpanContext.SpanID().String() + "-01"
+	if traceparent != want {
+		t.Fatalf("traceparent = %q, want %q", traceparent, want)
+	}
+}
+
+func TestTracer_RemoteParent(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	defer tp.Shutdown(context.Background())
+
+	sc, err := client.ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "")
+	if err != nil {
+		t.Fatalf("ParseTraceParent failed: %v", err)
+	}
+	c := client.NewHttpClient(ts.URL)
+	c.SetTracer(New(tp))
+	ctx := client.ContextWithSpanContext(context.Background(), sc)
+	if _, err := c.Do(&client.Request{Context: ctx, Path: "/"}); err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	spans := exp.GetSpans()
+	request := spans[len(spans)-1]
+	if request.SpanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || !request.Parent.IsRemote() {
+		t.Fatalf("request span not parented to remote context: %v", request.Parent)
+	}
+	if request.Status.Code == codes.Error {
+		t.Fatalf("unexpected error status: %v", request.Status)
+	}
+}
@@ -0,0 +1,198 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// 日志脱敏。
+//
+// 请求/响应日志在写出前经 redactor 处理：命中的请求头值、JSON / form / multipart 字段值、
+// URL 查询参数值与 userinfo 密码替换为占位符，正则命中的片段同样替换。
+// 默认开启，规则见 DefaultRedactConfig。
+
+// DefaultRedactReplacement 默认脱敏占位符。
+const DefaultRedactReplacement = "[REDACTED]"
+
+// RedactConfig 日志脱敏配置，名称均不区分大小写。
+type RedactConfig struct {
+	Headers     []string         // 请求/响应头名，如 Authorization
+	Fields      []string         // JSON、form、multipart 字段名，如 password
+	QueryParams []string         // URL 查询参数名，如 access_token
+	Patterns    []*regexp.Regexp // 对 URL、body 文本的任意匹配
+	Replacement string           // 占位符，空时使用 DefaultRedactReplacement
+}
+
+// DefaultRedactConfig 返回默认脱敏规则，可在此基础上追加后传给 SetRedaction。
+func DefaultRedactConfig() *RedactConfig {
+	secrets := []string{
+		"password", "passwd", "pwd", "secret", "client_secret",
+		"token", "access_token", "refresh_token", "id_token",
+		"api_key", "apikey", "auth", "credential", "private_key",
+	}
+	return &RedactConfig{
+		Headers: []string{
+			"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie",
+			"X-Api-Key", "X-Auth-Token", "X-Csrf-Token",
+		},
+		Fields:      secrets,
+		QueryParams: append(append([]string(nil), secrets...), "sig", "signature"),
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)\bbearer\s+[a-z0-9._~+/=-]+`),
+		},
+	}
+}
+
+// redactor 由 RedactConfig 编译而来，只读，可并发使用。
+type redactor struct {
+	headers     map[string]bool
+	jsonField   *regexp.Regexp // "field": value
+	formField   *regexp.Regexp // field=value（form body）
+	partField   *regexp.Regexp // multipart name="field" 的值
+	queryParam  *regexp.Regexp // field=value（URL 查询串）
+	patterns    []*regexp.Regexp
+	replacement string
+	template    string // 用于 ReplaceAllString 的占位符，$ 已转义
+}
+
+// defaultRedactor 编译一次默认规则供所有 client 共享。
+var defaultRedactor = sync.OnceValue(func() *redactor {
+	return newRedactor(DefaultRedactConfig())
+})
+
+// newRedactor 编译脱敏规则，cfg 为 nil 时不做任何处理。
+func newRedactor(cfg *RedactConfig) *redactor {
+	r := &redactor{
+		headers:     make(map[string]bool),
+		replacement: DefaultRedactReplacement,
+		template:    DefaultRedactReplacement,
+	}
+	if cfg == nil {
+		return r
+	}
+	if cfg.Replacement != "" {
+		r.replacement = cfg.Replacement
+	}
+	r.template = strings.ReplaceAll(r.replacement, "$", "$$")
+	for _, name := range cfg.Headers {
+		r.headers[http.CanonicalHeaderKey(name)] = true
+	}
+	if names := quoteNames(cfg.Fields); names != "" {
+		r.jsonField = regexp.MustCompile(`(?i)("(?:` + names + `)"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+		r.formField = regexp.MustCompile(`(?i)((?:^|&)(?:` + names + `)=)[^&\s]*`)
+		r.partField = regexp.MustCompile(`(?i)(name="(?:` + names + `)"[^\r\n]*\r?\n(?:[^\r\n]+\r?\n)*\r?\n)[^\r\n]*`)
+	}
+	if names := quoteNames(cfg.QueryParams); names != "" {
+		r.queryParam = regexp.MustCompile(`(?i)((?:^|&)(?:` + names + `)=)[^&]*`)
+	}
+	r.patterns = append(r.patterns, cfg.Patterns...)
+	return r
+}
+
+func quoteNames(names []string) string {
+	quoted := make([]string, 0, len(names))
+	for _, n := range names {
+		if n != "" {
+			quoted = append(quoted, regexp.QuoteMeta(n))
+		}
+	}
+	return strings.Join(quoted, "|")
+}
+
+// header 返回脱敏后的请求头副本，顺序/写法内部 header 不输出。
+func (r *redactor) header(h http.Header) http.Header {
+	_, order := h[headerOrderKey]
+	_, cased := h[headerCaseKey]
+	if h == nil || len(r.headers) == 0 && !order && !cased {
+		return h
+	}
+	out := h.Clone()
+	stripWireLayout(out)
+	for k, vs := range out {
+		if !r.headers[http.CanonicalHeaderKey(k)] {
+			continue
+		}
+		masked := make([]string, len(vs))
+		for i := range masked {
+			masked[i] = r.replacement
+		}
+		out[k] = masked
+	}
+	return out
+}
+
+// url 脱敏 URL 中的 userinfo 密码与查询参数。
+func (r *redactor) url(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return r.text(raw)
+	}
+	masked := false
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), r.replacement)
+		masked = true
+	}
+	if r.queryParam != nil && u.RawQuery != "" {
+		// RawQuery 原样输出，占位符不会被转义
+		u.RawQuery = r.queryParam.ReplaceAllString(u.RawQuery, "${1}"+r.template)
+	}
+	out := u.String()
+	if masked {
+		// userinfo 中的占位符会被转义，还原以便阅读
+		escaped := strings.TrimPrefix(url.UserPassword("", r.replacement).String(), ":")
+		out = strings.Replace(out, ":"+escaped+"@", ":"+r.replacement+"@", 1)
+	}
+	return r.text(out)
+}
+
+// body 脱敏请求/响应体中的 JSON、form、multipart 字段，无需区分 Content-Type。
+func (r *redactor) body(s string) string {
+	if s == "" {
+		return s
+	}
+	if r.jsonField != nil {
+		s = r.jsonField.ReplaceAllString(s, `${1}"`+r.template+`"`)
+		s = r.formField.ReplaceAllString(s, "${1}"+r.template)
+		s = r.partField.ReplaceAllString(s, "${1}"+r.template)
+	}
+	return r.text(s)
+}
+
+// text 应用正则规则。
+func (r *redactor) text(s string) string {
+	for _, p := range r.patterns {
+		s = p.ReplaceAllString(s, r.template)
+	}
+	return s
+}
+
+// error 脱敏 *url.Error 中携带的 URL，其它错误原样返回。
+func (r *redactor) error(err error) error {
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		return &url.Error{Op: ue.Op, URL: r.url(ue.URL), Err: ue.Err}
+	}
+	return err
+}
+
+// SetRedaction 设置日志脱敏规则；nil 恢复 DefaultRedactConfig，&RedactConfig{} 表示不脱敏。
+func (h *HttpClient) SetRedaction(cfg *RedactConfig) {
+	if cfg == nil {
+		cfg = DefaultRedactConfig()
+	}
+	r := newRedactor(cfg)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.redact = r
+}
+
+// getRedactor 返回当前脱敏规则。
+func (h *HttpClient) getRedactor() *redactor {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.redact
+}
@@ -0,0 +1,139 @@
+package reqtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+// AssertCalls 断言方法与路径（不含查询串）匹配的请求恰好发生 n 次，method 为空表示任意方法。
+func (m *Transport) AssertCalls(t testing.TB, method, path string, n int) {
+	t.Helper()
+	got := 0
+	for _, c := range m.Calls() {
+		if (method == "" || c.Method == method) && c.URL.Path == path {
+			got++
+		}
+	}
+	if got != n {
+		t.Errorf("reqtest: %s %s called %d times, want %d", method, path, got, n)
+	}
+}
+
+// AssertExpectations 断言每条路由至少被调用一次，且没有未匹配的请求。
+func (m *Transport) AssertExpectations(t testing.TB) {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.routes {
+		if r.calls == 0 {
+			t.Errorf("reqtest: route %s was never called", r)
+		}
+	}
+	for _, c := range m.calls {
+		if c.Route == nil {
+			t.Errorf("reqtest: unexpected request %s %s", c.Method, c.URL)
+		}
+	}
+}
+
+// AssertHeader 断言请求头 name 的第一个值为 want。
+func (c *Call) AssertHeader(t testing.TB, name, want string) {
+	t.Helper()
+	if c.missing(t) {
+		return
+	}
+	if got := c.Header.Get(name); got != want {
+		t.Errorf("reqtest: %s %s header %s = %q, want %q", c.Method, c.URL, name, got, want)
+	}
+}
+
+// AssertCookie 断言请求携带的 cookie name 的值为 want。
+func (c *Call) AssertCookie(t testing.TB, name, want string) {
+	t.Helper()
+	if c.missing(t) {
+		return
+	}
+	req := http.Request{Header: c.Header}
+	ck, err := req.Cookie(name)
+	if err != nil {
+		t.Errorf("reqtest: %s %s has no cookie %s", c.Method, c.URL, name)
+		return
+	}
+	if ck.Value != want {
+		t.Errorf("reqtest: %s %s cookie %s = %q, want %q", c.Method, c.URL, name, ck.Value, want)
+	}
+}
+
+// AssertQuery 断言查询参数 key 的第一个值为 want。
+func (c *Call) AssertQuery(t testing.TB, key, want string) {
+	t.Helper()
+	if c.missing(t) {
+		return
+	}
+	if got := c.URL.Query().Get(key); got != want {
+		t.Errorf("reqtest: %s %s query %s = %q, want %q", c.Method, c.URL, key, got, want)
+	}
+}
+
+// AssertBody 断言请求体与 want 完全一致。
+func (c *Call) AssertBody(t testing.TB, want string) {
+	t.Helper()
+	if c.missing(t) {
+		return
+	}
+	if string(c.Body) != want {
+		t.Errorf("reqtest: %s %s body = %q, want %q", c.Method, c.URL, c.Body, want)
+	}
+}
+
+// AssertJSON 断言请求体与 want 为语义相同的 JSON（忽略空白与字段顺序）。
+func (c *Call) AssertJSON(t testing.TB, want string) {
+	t.Helper()
+	if c.missing(t) {
+		return
+	}
+	var got, exp any
+	if err := json.Unmarshal(c.Body, &got); err != nil {
+		t.Errorf("reqtest: %s %s body is not JSON: %v", c.Method, c.URL, err)
+		return
+	}
+	if err := json.Unmarshal([]byte(want), &exp); err != nil {
+		t.Errorf("reqtest: invalid expected JSON: %v", err)
+		return
+	}
+	if !reflect.DeepEqual(got, exp) {
+		var compact bytes.Buffer
+		json.Compact(&compact, c.Body)
+		t.Errorf("reqtest: %s %s JSON body = %s, want %s", c.Method, c.URL, compact.String(), want)
+	}
+}
+
+// AssertForm 断言 form-urlencoded 请求体中字段 key 的第一个值为 want。
+func (c *Call) AssertForm(t testing.TB, key, want string) {
+	t.Helper()
+	if c.missing(t) {
+		return
+	}
+	form, err := url.ParseQuery(string(c.Body))
+	if err != nil {
+		t.Errorf("reqtest: %s %s body is not a form: %v", c.Method, c.URL, err)
+		return
+	}
+	if got := form.Get(key); got != want {
+		t.Errorf("reqtest: %s %s form %s = %q, want %q", c.Method, c.URL, key, got, want)
+	}
+}
+
+// missing c 为 nil（如没有请求时的 LastCall）时报告失败。
+func (c *Call) missing(t testing.TB) bool {
+	t.Helper()
+	if c == nil {
+		t.Errorf("reqtest: no request was made")
+		return true
+	}
+	return false
+}
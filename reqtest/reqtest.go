@@ -0,0 +1,298 @@
+// Package reqtest 提供可注入 HttpClient 的 mock 传输与断言工具，测试无需启动 httptest.Server。
+//
+//	m := reqtest.NewTransport()
+//	m.On("POST", "/login").Reply(200, `{"ok":true}`, "Set-Cookie", "sid=1")
+//	m.On("GET", "/flaky").Respond(reqtest.EOF(), reqtest.Text(200, "ok")) // 第一次断开，重试后成功
+//	c := client.NewHttpClient("https://api.example.com")
+//	m.Install(c)
+//	...
+//	m.AssertCalls(t, "GET", "/flaky", 2)
+//	m.LastCall().AssertCookie(t, "sid", "1")
+package reqtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	client "github.com/szwtdl/req"
+)
+
+// ErrNoRoute 请求没有匹配的路由。
+var ErrNoRoute = errors.New("reqtest: no route")
+
+// Responder 根据请求生成响应或错误。
+type Responder func(req *http.Request) (*http.Response, error)
+
+// Text 返回 status 与文本 body 的响应，header 为成对的名称与值。
+func Text(status int, body string, header ...string) Responder {
+	return Bytes(status, []byte(body), header...)
+}
+
+// Bytes 返回 status 与 body 的响应，header 为成对的名称与值。
+func Bytes(status int, body []byte, header ...string) Responder {
+	return func(req *http.Request) (*http.Response, error) {
+		h := make(http.Header)
+		for i := 0; i+1 < len(header); i += 2 {
+			h.Add(header[i], header[i+1])
+		}
+		return newResponse(req, status, h, body), nil
+	}
+}
+
+// JSON 返回以 v 的 JSON 编码为 body 的响应，Content-Type 默认为 application/json。
+func JSON(status int, v any, header ...string) Responder {
+	b, err := json.Marshal(v)
+	return func(req *http.Request) (*http.Response, error) {
+		if err != nil {
+			return nil, err
+		}
+		resp, _ := Bytes(status, b, header...)(req)
+		if resp.Header.Get("Content-Type") == "" {
+			resp.Header.Set("Content-Type", "application/json")
+		}
+		return resp, nil
+	}
+}
+
+// Error 返回固定的传输层错误。
+func Error(err error) Responder {
+	return func(*http.Request) (*http.Response, error) {
+		return nil, err
+	}
+}
+
+// EOF 模拟连接被对端提前关闭，client.IsRetryableError 视为可重试。
+func EOF() Responder {
+	return Error(io.EOF)
+}
+
+// Reset 模拟 connection reset by peer，client.IsRetryableError 视为可重试。
+func Reset() Responder {
+	return Error(&net.OpError{
+		Op:  "read",
+		Net: "tcp",
+		Err: &os.SyscallError{Syscall: "read", Err: syscall.ECONNRESET},
+	})
+}
+
+// Timeout 立即返回超时错误，client.IsTimeoutError 视为超时。
+func Timeout() Responder {
+	return Error(timeoutError{})
+}
+
+// Hang 阻塞直到请求被取消（client 超时或 ctx 结束），用于验证超时设置。
+func Hang() Responder {
+	return func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	}
+}
+
+// Delay 等待 d 后交给 r 处理，等待期间请求被取消则返回取消原因。
+func Delay(d time.Duration, r Responder) Responder {
+	return func(req *http.Request) (*http.Response, error) {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		select {
+		case <-t.C:
+			return r(req)
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// timeoutError 实现 net.Error 的超时错误。
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "reqtest: i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// Route 一条路由及其响应序列。
+type Route struct {
+	desc       string
+	match      func(*http.Request) bool
+	responders []Responder
+	calls      int
+}
+
+// Respond 追加响应序列：第 n 次调用使用第 n 个 Responder，用完后重复最后一个。
+func (r *Route) Respond(rs ...Responder) *Route {
+	r.responders = append(r.responders, rs...)
+	return r
+}
+
+// Reply 追加一个文本响应，header 为成对的名称与值。
+func (r *Route) Reply(status int, body string, header ...string) *Route {
+	return r.Respond(Text(status, body, header...))
+}
+
+// ReplyJSON 追加一个 JSON 响应。
+func (r *Route) ReplyJSON(status int, v any) *Route {
+	return r.Respond(JSON(status, v))
+}
+
+func (r *Route) String() string {
+	return r.desc
+}
+
+// Call 一次被捕获的请求。
+type Call struct {
+	Method string
+	URL    *url.URL
+	Header http.Header // 含 CookieJar 追加的 Cookie
+	Body   []byte
+	Route  *Route // 匹配的路由，nil 表示未匹配
+}
+
+// Transport mock 传输，实现 http.RoundTripper，并发安全。
+// 路由按注册顺序匹配，先注册的优先；没有路由匹配时返回 ErrNoRoute。
+type Transport struct {
+	mu     sync.Mutex
+	routes []*Route
+	calls  []*Call
+}
+
+// NewTransport 创建没有路由的 Transport。
+func NewTransport() *Transport {
+	return &Transport{}
+}
+
+// On 按方法与路径（不含查询串）精确匹配，method 为空表示任意方法。
+func (m *Transport) On(method, path string) *Route {
+	return m.add(method+" "+path, func(req *http.Request) bool {
+		return methodMatches(method, req) && req.URL.Path == path
+	})
+}
+
+// OnRegex 按方法与正则匹配，正则作用于路径加查询串（如 /users/1?expand=true）。
+func (m *Transport) OnRegex(method, pattern string) *Route {
+	re := regexp.MustCompile(pattern)
+	return m.add(method+" ~"+pattern, func(req *http.Request) bool {
+		return methodMatches(method, req) && re.MatchString(req.URL.RequestURI())
+	})
+}
+
+// OnFunc 按自定义函数匹配。
+func (m *Transport) OnFunc(match func(*http.Request) bool) *Route {
+	return m.add("func", match)
+}
+
+func (m *Transport) add(desc string, match func(*http.Request) bool) *Route {
+	r := &Route{desc: desc, match: match}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.routes = append(m.routes, r)
+	return r
+}
+
+func methodMatches(method string, req *http.Request) bool {
+	return method == "" || method == req.Method
+}
+
+// Install 让 c（含其 Session）的请求都经由 m。
+func (m *Transport) Install(c *client.HttpClient) {
+	c.SetRoundTripper(m)
+}
+
+// RoundTrip 实现 http.RoundTripper。
+func (m *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	call := &Call{Method: req.Method, URL: req.URL, Header: req.Header.Clone(), Body: body}
+
+	m.mu.Lock()
+	var responder Responder
+	for _, r := range m.routes {
+		if !r.match(req) {
+			continue
+		}
+		call.Route = r
+		if len(r.responders) > 0 {
+			responder = r.responders[min(r.calls, len(r.responders)-1)]
+		}
+		r.calls++
+		break
+	}
+	m.calls = append(m.calls, call)
+	m.mu.Unlock()
+
+	if call.Route == nil {
+		return nil, fmt.Errorf("%w for %s %s", ErrNoRoute, req.Method, req.URL)
+	}
+	if responder == nil {
+		return newResponse(req, http.StatusOK, make(http.Header), nil), nil
+	}
+	r2 := req.Clone(req.Context())
+	r2.Body = io.NopCloser(bytes.NewReader(body))
+	resp, err := responder(r2)
+	if err != nil {
+		return nil, err
+	}
+	resp.Request = req
+	if resp.Body == nil {
+		resp.Body = http.NoBody
+	}
+	return resp, nil
+}
+
+// Calls 返回已捕获的全部请求。
+func (m *Transport) Calls() []*Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*Call(nil), m.calls...)
+}
+
+// LastCall 返回最后一次请求，没有请求时返回 nil。
+func (m *Transport) LastCall() *Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.calls) == 0 {
+		return nil
+	}
+	return m.calls[len(m.calls)-1]
+}
+
+// Reset 清空已捕获的请求并重置各路由的响应序列，路由保留。
+func (m *Transport) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+	for _, r := range m.routes {
+		r.calls = 0
+	}
+}
+
+func newResponse(req *http.Request, status int, header http.Header, body []byte) *http.Response {
+	return &http.Response{
+		Status:        strconv.Itoa(status) + " " + http.StatusText(status),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,236 @@
+package reqtest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	client "github.com/szwtdl/req"
+)
+
+const base = "https://api.example.com"
+
+func newClient(m *Transport) *client.HttpClient {
+	c := client.NewHttpClient(base)
+	m.Install(c)
+	return c
+}
+
+// fakeTB 收集断言失败信息，用于验证断言本身。
+type fakeTB struct {
+	testing.TB
+	errs []string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.errs = append(f.errs, fmt.Sprintf(format, args...))
+}
+
+func TestTransport_Routing(t *testing.T) {
+	m := NewTransport()
+	m.On("GET", "/users").ReplyJSON(200, []string{"tom"})
+	m.OnRegex("GET", `^/users/\d+\?expand=true$`).Reply(200, "expanded")
+	m.OnRegex("", `^/users/\d+`).Reply(200, "user", "X-From", "regex")
+	m.OnFunc(func(r *http.Request) bool { return r.Header.Get("X-Admin") == "1" }).Reply(403, "denied")
+	c := newClient(m)
+
+	resp, err := c.Do(&client.Request{Method: "GET", Path: "/users"})
+	if err != nil || string(resp.Body) != `["tom"]` || resp.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("GET /users: %+v, %v", resp, err)
+	}
+	if b, _ := c.DoGet("/users/7?expand=true"); string(b) != "expanded" {
+		t.Fatalf("regex with query not matched: %q", b)
+	}
+	resp, err = c.Do(&client.Request{Method: "DELETE", Path: "/users/7"})
+	if err != nil || string(resp.Body) != "user" || resp.Header.Get("X-From") != "regex" {
+		t.Fatalf("DELETE /users/7: %+v, %v", resp, err)
+	}
+	resp, err = c.Do(&client.Request{Method: "GET", Path: "/other", Header: http.Header{"X-Admin": {"1"}}})
+	if err != nil || resp.StatusCode != 403 {
+		t.Fatalf("func route: %+v, %v", resp, err)
+	}
+
+	if _, err := c.DoGet("/missing"); !errors.Is(err, ErrNoRoute) {
+		t.Fatalf("expected ErrNoRoute, got %v", err)
+	}
+	if n := len(m.Calls()); n != 5 || m.LastCall().Route != nil {
+		t.Fatalf("unmatched request should be captured: %d calls", n)
+	}
+	m.AssertCalls(t, "GET", "/users", 1)
+	m.AssertCalls(t, "", "/users/7", 2)
+}
+
+func TestTransport_Sequence(t *testing.T) {
+	m := NewTransport()
+	m.On("GET", "/job").Reply(202, "pending").Reply(202, "running").Reply(200, "done")
+	c := newClient(m)
+
+	var got []string
+	for range 4 {
+		b, _ := c.DoGet("/job")
+		got = append(got, string(b))
+	}
+	if strings.Join(got, ",") != "pending,running,done,done" {
+		t.Fatalf("unexpected sequence: %v", got)
+	}
+
+	m.Reset()
+	if b, _ := c.DoGet("/job"); string(b) != "pending" || len(m.Calls()) != 1 {
+		t.Fatalf("Reset should restart the sequence: %q", b)
+	}
+}
+
+func TestTransport_FaultsAreRetried(t *testing.T) {
+	m := NewTransport()
+	m.On("GET", "/eof").Respond(EOF(), Text(200, "ok"))
+	m.On("GET", "/reset").Respond(Reset(), Reset(), Text(200, "ok"))
+	m.On("GET", "/down").Respond(EOF())
+	c := newClient(m)
+
+	if b, err := c.DoGet("/eof"); err != nil || string(b) != "ok" {
+		t.Fatalf("EOF should be retried: %q, %v", b, err)
+	}
+	if b, err := c.DoGet("/reset"); err != nil || string(b) != "ok" {
+		t.Fatalf("connection reset should be retried: %q, %v", b, err)
+	}
+	if _, err := c.DoGet("/down"); !client.IsRetryableError(err) {
+		t.Fatalf("expected retryable error after exhausting retries, got %v", err)
+	}
+	m.AssertCalls(t, "GET", "/eof", 2)
+	m.AssertCalls(t, "GET", "/reset", 3)
+	m.AssertCalls(t, "GET", "/down", 4) // 首次 + 3 次重试
+}
+
+func TestTransport_Timeouts(t *testing.T) {
+	m := NewTransport()
+	m.On("GET", "/timeout").Respond(Timeout())
+	m.On("GET", "/hang").Respond(Hang())
+	m.On("GET", "/slow").Respond(Delay(20*time.Millisecond, Text(200, "slow")))
+	c := newClient(m)
+
+	if _, err := c.DoGet("/timeout"); err == nil || err.Error() != "请求超时" {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	m.AssertCalls(t, "GET", "/timeout", 1) // 超时不重试
+
+	c.SetTimeout(100 * time.Millisecond)
+	start := time.Now()
+	if _, err := c.DoGet("/hang"); err == nil || err.Error() != "请求超时" {
+		t.Fatalf("expected client timeout, got %v", err)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Fatalf("hang was not cancelled by the client timeout: %v", d)
+	}
+	if b, err := c.DoGet("/slow"); err != nil || string(b) != "slow" {
+		t.Fatalf("delayed reply: %q, %v", b, err)
+	}
+}
+
+func TestTransport_SessionAndAssertions(t *testing.T) {
+	m := NewTransport()
+	m.On("POST", "/login").Reply(200, "ok", "Set-Cookie", "sid=abc; Path=/")
+	m.On("POST", "/items").ReplyJSON(201, map[string]int{"id": 1})
+	m.On("GET", "/me").Reply(200, "me")
+	c := newClient(m)
+	s := client.NewSession()
+
+	if _, err := c.DoPostWithSession(s, "/login", map[string]string{"user": "tom"}); err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	m.LastCall().AssertForm(t, "user", "tom")
+	if s.GetCookieValue(base, "sid") != "abc" {
+		t.Fatal("Set-Cookie from the mock should reach the session jar")
+	}
+
+	_, err := c.Do(&client.Request{
+		Method:  "POST",
+		Path:    "/items?dry=1",
+		Header:  http.Header{"Content-Type": {"application/json"}},
+		Body:    []byte(`{"name": "pen", "qty": 2}`),
+		Session: s,
+	})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	call := m.LastCall()
+	call.AssertCookie(t, "sid", "abc")
+	call.AssertHeader(t, "Content-Type", "application/json")
+	call.AssertQuery(t, "dry", "1")
+	call.AssertJSON(t, `{"qty":2,"name":"pen"}`)
+	call.AssertBody(t, `{"name": "pen", "qty": 2}`)
+
+	// 断言失败时报告错误
+	ft := &fakeTB{}
+	call.AssertCookie(ft, "sid", "other")
+	call.AssertCookie(ft, "missing", "")
+	call.AssertHeader(ft, "Content-Type", "text/plain")
+	call.AssertQuery(ft, "dry", "0")
+	call.AssertJSON(ft, `{"qty":3,"name":"pen"}`)
+	call.AssertBody(ft, "x")
+	call.AssertForm(ft, "name", "pen")
+	m.AssertCalls(ft, "POST", "/items", 2)
+	m.AssertExpectations(ft) // /me 未调用
+	(*Call)(nil).AssertBody(ft, "")
+	if len(ft.errs) != 10 {
+		t.Fatalf("expected 10 assertion failures, got %d:\n%s", len(ft.errs), strings.Join(ft.errs, "\n"))
+	}
+	if !strings.Contains(ft.errs[8], "GET /me was never called") {
+		t.Fatalf("unexpected AssertExpectations message: %s", ft.errs[8])
+	}
+
+	c.DoGet("/me")
+	m.AssertExpectations(t)
+	c.DoGet("/nope")
+	ft = &fakeTB{}
+	m.AssertExpectations(ft)
+	if len(ft.errs) != 1 || !strings.Contains(ft.errs[0], "unexpected request GET "+base+"/nope") {
+		t.Fatalf("unmatched request should fail AssertExpectations: %v", ft.errs)
+	}
+}
+
+func TestTransport_NoInternalHeaders(t *testing.T) {
+	m := NewTransport()
+	m.On("GET", "/x").Reply(200, "ok")
+	c := client.NewHttpClient("http://api.example.com")
+	m.Install(c)
+	c.SetHeaderOrder("user-agent", "x-token")
+	c.PreserveHeaderCase(true)
+
+	if _, err := c.Do(&client.Request{Method: "GET", Path: "/x", Header: http.Header{"X-Token": {"t"}}}); err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	call := m.LastCall()
+	for k := range call.Header {
+		if strings.HasPrefix(k, "X-Req-") {
+			t.Fatalf("internal header %s reached the mock: %v", k, call.Header)
+		}
+	}
+	call.AssertHeader(t, "X-Token", "t")
+}
+
+func TestTransport_UninstallAndCassette(t *testing.T) {
+	m := NewTransport()
+	m.On("GET", "/x").Reply(200, "mock")
+	c := newClient(m)
+	cas, err := client.NewCassette(t.TempDir()+"/x.json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// cassette 包在 mock 之外，可离线录制
+	c.UseCassette(cas)
+	if b, _ := c.DoGet("/x"); string(b) != "mock" || len(cas.Interactions()) != 1 {
+		t.Fatalf("cassette should record the mock response: %q", b)
+	}
+	c.UseCassette(nil)
+
+	c.SetRoundTripper(nil)
+	c.SetTimeout(time.Second)
+	if _, err := c.DoGet("http://127.0.0.1:1/x"); err == nil || len(m.Calls()) != 1 {
+		t.Fatalf("SetRoundTripper(nil) should restore the network: %v, %d calls", err, len(m.Calls()))
+	}
+}
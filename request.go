@@ -2,8 +2,10 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"net/http"
 	"net/url"
@@ -16,9 +18,7 @@ func (h *HttpClient) DoGet(path string) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
-	for k, v := range h.GetHeader() {
-		req.Header.Set(k, v)
-	}
+	h.applyHeaders(req, nil)
 	return h.doRequest(req)
 }
 
@@ -28,10 +28,10 @@ func (h *HttpClient) DoGetRaw(path string) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create GET request: %w", err)
 	}
-	for k, v := range h.GetHeader() {
-		req.Header.Set(k, v)
+	h.applyHeaders(req, nil)
+	if lg := h.newRequestLog(req); lg.enabled(LogEventSend) {
+		lg.log(LogEventSend, "GET 请求准备发送", "url", lg.rd.url(req.URL.String()), "headers", lg.rd.header(req.Header))
 	}
-	h.LogInfo("GET 请求准备发送", "url", req.URL.String(), "headers", req.Header)
 	return h.doRequest(req)
 }
 
@@ -45,9 +45,7 @@ func (h *HttpClient) DoPost(path string, postData map[string]string) ([]byte, er
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
-	for k, v := range h.GetHeader() {
-		req.Header.Set(k, v)
-	}
+	h.applyHeaders(req, nil)
 	return h.doRequest(req)
 }
 
@@ -69,9 +67,7 @@ func (h *HttpClient) DoPostAny(path string, postData interface{}) ([]byte, error
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
-	for k, v := range headers {
-		req.Header.Set(k, v)
-	}
+	h.applyHeaders(req, nil)
 	return h.doRequest(req)
 }
 
@@ -81,10 +77,10 @@ func (h *HttpClient) DoPostRaw(path, rawBody string) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	for k, v := range h.GetHeader() {
-		req.Header.Set(k, v)
+	h.applyHeaders(req, nil)
+	if lg := h.newRequestLog(req); lg.enabled(LogEventSend) {
+		lg.log(LogEventSend, "请求体内容", "raw", lg.body([]byte(rawBody), req.Header.Get("Content-Type")))
 	}
-	h.LogInfo("请求体内容", "raw", rawBody)
 	return h.doRequest(req)
 }
 
@@ -101,9 +97,7 @@ func (h *HttpClient) DoPostMultipart(path string, fields map[string]string) ([]b
 	if err != nil {
 		return nil, err
 	}
-	for k, v := range h.GetHeader() {
-		req.Header.Set(k, v)
-	}
+	h.applyHeaders(req, nil)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	return h.doRequest(req)
 }
@@ -118,9 +112,7 @@ func (h *HttpClient) DoPut(path string, putData map[string]string) ([]byte, erro
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
-	for k, v := range h.GetHeader() {
-		req.Header.Set(k, v)
-	}
+	h.applyHeaders(req, nil)
 	return h.doRequest(req)
 }
 
@@ -130,9 +122,7 @@ func (h *HttpClient) DoPutRaw(path string, raw []byte) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
-	for k, v := range h.GetHeader() {
-		req.Header.Set(k, v)
-	}
+	h.applyHeaders(req, nil)
 	return h.doRequest(req)
 }
 
@@ -157,9 +147,7 @@ func (h *HttpClient) DoDelete(path string, body ...map[string]string) ([]byte, e
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
-	for k, v := range headers {
-		req.Header.Set(k, v)
-	}
+	h.applyHeaders(req, nil)
 	return h.doRequest(req)
 }
 
@@ -169,9 +157,7 @@ func (h *HttpClient) DoDeleteRaw(path, rawBody string) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	for k, v := range h.GetHeader() {
-		req.Header.Set(k, v)
-	}
+	h.applyHeaders(req, nil)
 	return h.doRequest(req)
 }
 
@@ -185,9 +171,7 @@ func (h *HttpClient) DoPatch(path string, patchData map[string]string) ([]byte,
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
-	for k, v := range h.GetHeader() {
-		req.Header.Set(k, v)
-	}
+	h.applyHeaders(req, nil)
 	return h.doRequest(req)
 }
 
@@ -201,9 +185,7 @@ func (h *HttpClient) DoPatchAny(path string, patchData interface{}) ([]byte, err
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
-	for k, v := range h.GetHeader() {
-		req.Header.Set(k, v)
-	}
+	h.applyHeaders(req, nil)
 	return h.doRequest(req)
 }
 
@@ -213,9 +195,7 @@ func (h *HttpClient) DoPatchRaw(path, rawBody string) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	for k, v := range h.GetHeader() {
-		req.Header.Set(k, v)
-	}
+	h.applyHeaders(req, nil)
 	return h.doRequest(req)
 }
 
@@ -225,9 +205,7 @@ func (h *HttpClient) DoHead(path string) (http.Header, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
-	for k, v := range h.GetHeader() {
-		req.Header.Set(k, v)
-	}
+	h.applyHeaders(req, nil)
 	if h.semaphore != nil {
 		h.semaphore <- struct{}{}
 		defer func() { <-h.semaphore }()
@@ -247,9 +225,7 @@ func (h *HttpClient) DoOptions(path string) (http.Header, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
-	for k, v := range h.GetHeader() {
-		req.Header.Set(k, v)
-	}
+	h.applyHeaders(req, nil)
 	if h.semaphore != nil {
 		h.semaphore <- struct{}{}
 		defer func() { <-h.semaphore }()
@@ -268,13 +244,7 @@ func (h *HttpClient) DoGetWithSession(s *Session, path string) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
-	headers := h.GetHeader()
-	for k, v := range s.getHeaders() {
-		headers[k] = v // session header 优先级更高
-	}
-	for k, v := range headers {
-		req.Header.Set(k, v)
-	}
+	h.applyHeaders(req, s)
 	return h.doRequestWith(req, h.clientWithSession(s))
 }
 
@@ -292,12 +262,44 @@ func (h *HttpClient) DoPostWithSession(s *Session, path string, postData map[str
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
-	for k, v := range headers {
-		req.Header.Set(k, v)
-	}
+	h.applyHeaders(req, s)
 	return h.doRequestWith(req, h.clientWithSession(s))
 }
 
+// Do 发送通用请求，支持任意方法、多值请求头及可选 Session，返回完整响应。
+func (h *HttpClient) Do(r *Request) (*Response, error) {
+	req, err := h.newRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	if r.Session != nil {
+		return h.send(req, h.clientWithSession(r.Session))
+	}
+	return h.send(req, h.client)
+}
+
+// newRequest 按 Request 构建 http.Request，并合并 client/Session/单次请求的 header。
+func (h *HttpClient) newRequest(r *Request) (*http.Request, error) {
+	method := r.Method
+	if method == "" {
+		method = "GET"
+	}
+	var body io.Reader
+	if len(r.Body) > 0 {
+		body = bytes.NewReader(r.Body)
+	}
+	ctx := r.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, h.buildFullURL(r.Path), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	h.applyHeaders(req, r.Session, r.Header)
+	return req, nil
+}
+
 // encodeBody 根据 Content-Type header 将 map 序列化为 JSON 或 form-urlencoded。
 func encodeBody(headers map[string]string, data map[string]string) ([]byte, error) {
 	contentType := "application/json"
@@ -358,3 +358,44 @@ func TestDoOptions(t *testing.T) {
 	}
 }
 
+// ----- Do（通用请求 + 单次请求 header）-----
+
+func TestDo_PerRequestHeaderOverridesClientAndSession(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("expected PUT, got %s", r.Method)
+		}
+		if got := r.Header.Values("Accept"); len(got) != 2 || got[0] != "text/html" {
+			t.Errorf("expected per-request Accept values, got %v", got)
+		}
+		if r.Header.Get("X-Session") != "s1" {
+			t.Errorf("expected session header, got %s", r.Header.Get("X-Session"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Reply", "yes")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	}))
+	defer ts.Close()
+
+	c := NewHttpClient(ts.URL)
+	c.AddHeader("Accept", "*/*")
+	s := NewSession()
+	s.SetHeader("X-Session", "s1")
+	resp, err := c.Do(&Request{
+		Method:  http.MethodPut,
+		Path:    "/items/1",
+		Header:  http.Header{"accept": {"text/html", "application/json"}},
+		Body:    []byte("payload"),
+		Session: s,
+	})
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusCreated || string(resp.Body) != "payload" {
+		t.Fatalf("unexpected response: %d %s", resp.StatusCode, resp.Body)
+	}
+	if resp.Header.Get("X-Reply") != "yes" {
+		t.Fatalf("response header missing: %v", resp.Header)
+	}
+}
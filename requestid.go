@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// 请求关联 ID。
+//
+// 每次逻辑请求（含重试）分配一个 ID：优先取请求上已有的 ID 请求头，其次取 ctx 中的
+// ContextWithRequestID，否则生成新的 UUIDv4。ID 附加到该请求的每条日志（request_id 字段）、
+// 返回的错误（RequestError）、指标（RequestMetric.RequestID）与 Response 上；
+// 默认不发给服务端（固定的 ID 请求头本身可作为客户端指纹），需要时用 SetRequestIDHeader 开启。
+
+// DefaultRequestIDHeader 常用的关联 ID 请求头名，可传给 SetRequestIDHeader。
+const DefaultRequestIDHeader = "X-Request-Id"
+
+// requestIDKey context 中的关联 ID。
+type requestIDKey struct{}
+
+// ContextWithRequestID 返回携带关联 ID 的 ctx，请求将沿用该 ID 而不再生成。
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext 取出 ctx 中的关联 ID，没有时返回空串。
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestError 请求失败时返回的错误，携带关联 ID；错误信息与原始错误一致，
+// errors.Is/As 可穿透到原始错误，ID 通过 RequestIDFromError 取出。
+type RequestError struct {
+	RequestID string
+	Err       error
+}
+
+func (e *RequestError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// RequestIDFromError 取出错误中的关联 ID，没有时返回空串。
+func RequestIDFromError(err error) string {
+	var re *RequestError
+	if errors.As(err, &re) {
+		return re.RequestID
+	}
+	return ""
+}
+
+// SetRequestIDHeader 设置发送关联 ID 的请求头名（如 DefaultRequestIDHeader），
+// 同时从该请求头读取调用方已设置的 ID；默认空串，不发送（日志、错误与指标中仍带 ID）。
+func (h *HttpClient) SetRequestIDHeader(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.requestIDHeader = name
+}
+
+// SetRequestIDGenerator 设置关联 ID 生成函数，nil 恢复默认的 UUIDv4。
+func (h *HttpClient) SetRequestIDGenerator(gen func() string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.requestIDGen = gen
+}
+
+// assignRequestID 为 req 确定关联 ID，写入请求头并返回绑定了 ID 的请求副本。
+func (h *HttpClient) assignRequestID(req *http.Request) (*http.Request, string) {
+	h.mu.RLock()
+	header, gen := h.requestIDHeader, h.requestIDGen
+	h.mu.RUnlock()
+
+	var id string
+	if header != "" {
+		id = req.Header.Get(header)
+	}
+	if id == "" {
+		id = RequestIDFromContext(req.Context())
+	}
+	if id == "" {
+		if gen == nil {
+			gen = newUUID
+		}
+		id = gen()
+	}
+	if header != "" {
+		req.Header.Set(header, id)
+	}
+	return req.WithContext(ContextWithRequestID(req.Context(), id)), id
+}
+
+// newUUID 生成随机 UUIDv4。
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var uuidRe = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestRequestID_GeneratedAndSharedAcrossRetries(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		ids []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ids = append(ids, r.Header.Get("X-Request-Id"))
+		n := len(ids)
+		mu.Unlock()
+		if n == 1 {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	core, logs := observer.New(zap.DebugLevel)
+	c := NewHttpClient(ts.URL)
+	c.SetRequestIDHeader(DefaultRequestIDHeader)
+	c.SetLogger(zap.New(core).Sugar())
+	resp, err := c.Do(&Request{Path: "/"})
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if !uuidRe.MatchString(resp.RequestID) {
+		t.Fatalf("unexpected generated id: %q", resp.RequestID)
+	}
+	if len(ids) != 2 || ids[0] != resp.RequestID || ids[1] != resp.RequestID {
+		t.Fatalf("retries should reuse the request id: %v vs %q", ids, resp.RequestID)
+	}
+	for _, msg := range []string{"请求准备发送", "请求重试", "请求成功"} {
+		entries := logs.FilterMessage(msg).All()
+		if len(entries) == 0 || entries[0].ContextMap()["request_id"] != resp.RequestID {
+			t.Fatalf("%q log missing request_id: %v", msg, entries)
+		}
+	}
+
+	// 每次逻辑请求使用新的 ID
+	resp2, _ := c.Do(&Request{Path: "/"})
+	if resp2.RequestID == resp.RequestID {
+		t.Fatal("each request should get a new id")
+	}
+}
+
+func TestRequestID_ContextHeaderAndGenerator(t *testing.T) {
+	var got sync.Map
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.Store(r.URL.Path, r.Header.Get("X-Request-Id")+"|"+r.Header.Get("X-Trace"))
+	}))
+	defer ts.Close()
+
+	c := NewHttpClient(ts.URL)
+	// 默认不发送 ID 请求头
+	if resp, _ := c.Do(&Request{Path: "/default"}); !uuidRe.MatchString(resp.RequestID) {
+		t.Fatalf("id should be assigned without the header: %q", resp.RequestID)
+	}
+
+	c.SetRequestIDHeader(DefaultRequestIDHeader)
+	ctx := ContextWithRequestID(context.Background(), "from-ctx")
+	if resp, _ := c.Do(&Request{Context: ctx, Path: "/ctx"}); resp.RequestID != "from-ctx" {
+		t.Fatalf("context id not used: %q", resp.RequestID)
+	}
+	if resp, _ := c.Do(&Request{Path: "/hdr", Header: http.Header{"X-Request-Id": {"explicit"}}}); resp.RequestID != "explicit" {
+		t.Fatalf("explicit header not used: %q", resp.RequestID)
+	}
+
+	c.SetRequestIDHeader("X-Trace")
+	c.SetRequestIDGenerator(func() string { return "custom" })
+	c.Do(&Request{Path: "/custom"})
+
+	c.SetRequestIDHeader("")
+	resp, _ := c.Do(&Request{Path: "/off"})
+	if resp.RequestID != "custom" {
+		t.Fatalf("id should still be assigned when header is disabled: %q", resp.RequestID)
+	}
+
+	for path, want := range map[string]string{
+		"/default": "|",
+		"/ctx":     "from-ctx|",
+		"/hdr":     "explicit|",
+		"/custom":  "|custom",
+		"/off":     "|",
+	} {
+		if v, _ := got.Load(path); v != want {
+			t.Errorf("%s: headers = %q, want %q", path, v, want)
+		}
+	}
+}
+
+func TestRequestID_OnErrors(t *testing.T) {
+	c := NewHttpClient("http://127.0.0.1:1")
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	_, err := c.Do(&Request{Context: ctx, Path: "/"})
+	if err == nil {
+		t.Fatal("expected connection error")
+	}
+	if RequestIDFromError(err) != "req-1" {
+		t.Fatalf("error missing request id: %v", err)
+	}
+	var re *RequestError
+	if !errors.As(err, &re) || re.Err.Error() != "连接被拒绝" {
+		t.Fatalf("unexpected wrapped error: %v", err)
+	}
+	if err.Error() != "连接被拒绝" {
+		t.Fatalf("unexpected error message: %q", err.Error())
+	}
+	if RequestIDFromError(errors.New("plain")) != "" {
+		t.Fatal("plain errors have no request id")
+	}
+}
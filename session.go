@@ -6,20 +6,34 @@ import (
 	"net/textproto"
 	"net/url"
 	"sync"
+
+	utls "github.com/refraction-networking/utls"
 )
 
 // Session 代表一个独立的 HTTP 会话，拥有独立的 CookieJar。
 // 适用于多账号/多用户并发场景，各 goroutine 持有各自的 Session。
 type Session struct {
 	jar     http.CookieJar
-	headers map[string]string
+	headers http.Header
 	mu      sync.RWMutex
+
+	headerOrder  []string          // 请求头写出顺序，非空时覆盖 client 设置
+	headerCase   map[string]string // Canonical key -> 原始写法
+	preserveCase bool
+
+	ja3Profile   string          // 指纹轮换时本 Session 固定使用的 profile
+	transport    *http.Transport // 指纹轮换/出口地址粘滞时本 Session 独占的连接池
+	transportGen uint64
+	localSeed    uint64 // 出口地址粘滞时本 Session 的轮询起点
+	localSeeded  bool
+
+	tlsSessionCache utls.ClientSessionCache // 本 Session 独立的 uTLS 会话恢复缓存
 }
 
 // NewSession 创建一个新的独立 Session。
 func NewSession() *Session {
 	jar, _ := cookiejar.New(nil)
-	return &Session{jar: jar, headers: make(map[string]string)}
+	return &Session{jar: jar, headers: make(http.Header), headerCase: make(map[string]string)}
 }
 
 // SetCookies 设置指定 URL 域名下的 Cookie。
@@ -66,17 +80,98 @@ func (s *Session) GetCookieValue(rawURL, name string) string {
 func (s *Session) SetHeader(name, value string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.headers[textproto.CanonicalMIMEHeaderKey(name)] = value
+	s.headers.Set(name, value)
+	s.headerCase[textproto.CanonicalMIMEHeaderKey(name)] = name
+}
+
+// AppendHeader 为本 Session 追加一个请求头值（同名 header 保留已有值）。
+func (s *Session) AppendHeader(name, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.headers.Add(name, value)
+	s.headerCase[textproto.CanonicalMIMEHeaderKey(name)] = name
+}
+
+// SetHeaderValues 将本 Session 的指定请求头替换为给定的多个值。
+func (s *Session) SetHeaderValues(name string, values ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := textproto.CanonicalMIMEHeaderKey(name)
+	s.headers[key] = append([]string(nil), values...)
+	s.headerCase[key] = name
 }
 
-// getHeaders 返回 Session headers 的副本（线程安全）。
+// DelHeader 删除本 Session 的指定请求头（删除后回退使用 client 级别的同名 header）。
+func (s *Session) DelHeader(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := textproto.CanonicalMIMEHeaderKey(name)
+	s.headers.Del(key)
+	delete(s.headerCase, key)
+}
+
+// HeaderValues 返回本 Session 指定请求头的所有值（副本）。
+func (s *Session) HeaderValues(name string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.headers.Values(name)...)
+}
+
+// Headers 返回本 Session 所有请求头（含多值）的副本。
+func (s *Session) Headers() http.Header {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.headers.Clone()
+}
+
+// SetHeaderOrder 设置本 Session 的请求头写出顺序（非空时覆盖 client 级别的顺序）。
+func (s *Session) SetHeaderOrder(names ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.headerOrder = append([]string(nil), names...)
+}
+
+// PreserveHeaderCase 开启后本 Session 的请求头按原始大小写写出。
+func (s *Session) PreserveHeaderCase(enable bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.preserveCase = enable
+}
+
+// getHeaders 返回 Session headers 的副本（线程安全）；多值 header 只返回第一个值。
 func (s *Session) getHeaders() map[string]string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	cp := make(map[string]string, len(s.headers))
-	for k, v := range s.headers {
-		cp[k] = v
+	return firstValues(s.headers)
+}
+
+// JA3Profile 返回本 Session 固定使用的 JA3 profile（未启用指纹轮换时为空）。
+func (s *Session) JA3Profile() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.ja3Profile
+}
+
+// CloseIdleConnections 关闭本 Session 独占连接池中的空闲连接。
+func (s *Session) CloseIdleConnections() {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.transport != nil {
+		s.transport.CloseIdleConnections()
 	}
-	return cp
 }
 
+// EnableTLSSessionCache 为本 Session 启用独立的 uTLS 会话恢复缓存（JA3 模式下生效），
+// 会话票据不与 client 及其他 Session 共享；size<=0 时关闭并回退使用 client 级别缓存。
+func (s *Session) EnableTLSSessionCache(size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tlsSessionCache = nil
+	if size > 0 {
+		s.tlsSessionCache = utls.NewLRUClientSessionCache(size)
+	}
+	if s.transport != nil {
+		s.transport.CloseIdleConnections()
+		s.transport = nil
+	}
+}
@@ -161,3 +161,29 @@ func TestSession_CookieIsolation(t *testing.T) {
 	}
 }
 
+func TestSession_MultiValueHeaders(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Values("X-Forwarded-For"); len(got) != 2 {
+			t.Errorf("expected 2 X-Forwarded-For values, got %v", got)
+		}
+		if got := r.Header.Get("X-Client"); got != "" {
+			t.Errorf("deleted session header should fall back to client, got %s", got)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	c := NewHttpClient(ts.URL)
+	c.AppendHeader("X-Forwarded-For", "9.9.9.9")
+	s := NewSession()
+	s.AppendHeader("X-Forwarded-For", "1.1.1.1")
+	s.AppendHeader("X-Forwarded-For", "2.2.2.2")
+	s.SetHeader("X-Client", "session")
+	s.DelHeader("X-Client")
+	if len(s.HeaderValues("X-Forwarded-For")) != 2 {
+		t.Fatalf("unexpected session values: %v", s.Headers())
+	}
+	if _, err := c.DoGetWithSession(s, "/"); err != nil {
+		t.Fatalf("DoGetWithSession failed: %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"net"
+	"syscall"
+	"time"
+)
+
+// defaultDialTimeout 未配置 DialTimeout 时的建连超时，与 net/http 默认 Transport 一致。
+const defaultDialTimeout = 30 * time.Second
+
+// socketOptions 由 TransportConfig 派生的拨号与 socket 参数。
+type socketOptions struct {
+	dialTimeout         time.Duration
+	tlsHandshakeTimeout time.Duration
+	keepAlive           time.Duration
+	keepAliveInterval   time.Duration
+	keepAliveCount      int
+	disableNoDelay      bool
+	mark                int
+	fastOpen            bool
+	control             func(network, address string, c syscall.RawConn) error
+}
+
+// newSocketOptions 提取 TransportConfig 中的拨号参数，tc 为 nil 时返回零值。
+func newSocketOptions(tc *TransportConfig) socketOptions {
+	if tc == nil {
+		return socketOptions{}
+	}
+	return socketOptions{
+		dialTimeout:         tc.DialTimeout,
+		tlsHandshakeTimeout: tc.TLSHandshakeTimeout,
+		keepAlive:           tc.KeepAlive,
+		keepAliveInterval:   tc.KeepAliveInterval,
+		keepAliveCount:      tc.KeepAliveCount,
+		disableNoDelay:      tc.DisableNoDelay,
+		mark:                tc.SocketMark,
+		fastOpen:            tc.TCPFastOpen,
+		control:             tc.Control,
+	}
+}
+
+// newDialer 按 socket 参数创建 net.Dialer；local 非 nil 时绑定源地址。
+func (h *HttpClient) newDialer(local net.IP) *net.Dialer {
+	o := &h.sockOpts
+	d := &net.Dialer{Timeout: o.dialTimeout}
+	if d.Timeout <= 0 {
+		d.Timeout = defaultDialTimeout
+	}
+	if local != nil {
+		d.LocalAddr = &net.TCPAddr{IP: local}
+	}
+	if o.keepAlive < 0 {
+		d.KeepAlive = -1
+	} else {
+		d.KeepAliveConfig = net.KeepAliveConfig{
+			Enable:   true,
+			Idle:     o.keepAlive,
+			Interval: o.keepAliveInterval,
+			Count:    o.keepAliveCount,
+		}
+	}
+	if o.mark != 0 || o.fastOpen || o.control != nil {
+		d.Control = func(network, address string, c syscall.RawConn) error {
+			if err := setPlatformSockOpts(c, o); err != nil {
+				return err
+			}
+			if o.control != nil {
+				return o.control(network, address, c)
+			}
+			return nil
+		}
+	}
+	return d
+}
+
+// dialSocket 使用 newDialer 建立 TCP 连接，并应用需在 connect 之后设置的选项。
+func (h *HttpClient) dialSocket(ctx context.Context, network, addr string, local net.IP) (net.Conn, error) {
+	conn, err := h.newDialer(local).DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	// Go 在建连后默认开启 TCP_NODELAY，Control 中的设置会被覆盖，故在此关闭
+	if tc, ok := conn.(*net.TCPConn); ok && h.sockOpts.disableNoDelay {
+		if err := tc.SetNoDelay(false); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
@@ -0,0 +1,29 @@
+package client
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// setPlatformSockOpts 在 connect 之前设置 Linux 专有的 socket 选项。
+func setPlatformSockOpts(c syscall.RawConn, o *socketOptions) error {
+	if o.mark == 0 && !o.fastOpen {
+		return nil
+	}
+	var sockErr error
+	err := c.Control(func(fd uintptr) {
+		if o.mark != 0 {
+			if sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_MARK, o.mark); sockErr != nil {
+				return
+			}
+		}
+		if o.fastOpen {
+			sockErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_FASTOPEN_CONNECT, 1)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
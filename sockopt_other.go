@@ -0,0 +1,16 @@
+//go:build !linux
+
+package client
+
+import (
+	"errors"
+	"syscall"
+)
+
+// setPlatformSockOpts 非 Linux 平台不支持 SO_MARK 与 TCP Fast Open。
+func setPlatformSockOpts(c syscall.RawConn, o *socketOptions) error {
+	if o.mark != 0 || o.fastOpen {
+		return errors.New("SocketMark and TCPFastOpen are only supported on Linux")
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package client
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestTransportConfig_PhaseTimeouts(t *testing.T) {
+	c := NewHttpClientWithTransport("http://example.com", &TransportConfig{
+		TLSHandshakeTimeout:   3 * time.Second,
+		ResponseHeaderTimeout: 4 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	})
+	tr := c.transport
+	if tr.TLSHandshakeTimeout != 3*time.Second || tr.ResponseHeaderTimeout != 4*time.Second || tr.ExpectContinueTimeout != time.Second {
+		t.Fatalf("phase timeouts not applied: %v %v %v", tr.TLSHandshakeTimeout, tr.ResponseHeaderTimeout, tr.ExpectContinueTimeout)
+	}
+	c.SetTimeout(time.Minute)
+	if d := c.newDialer(nil); d.Timeout != defaultDialTimeout || !d.KeepAliveConfig.Enable {
+		t.Fatalf("dial timeout should default to %v regardless of request timeout, got %+v", defaultDialTimeout, d)
+	}
+	c = NewHttpClientWithTransport("http://example.com", &TransportConfig{DialTimeout: 2 * time.Second, KeepAlive: -1})
+	c.SetTimeout(time.Minute)
+	if d := c.newDialer(nil); d.Timeout != 2*time.Second || d.KeepAlive >= 0 {
+		t.Fatalf("dial timeout/keep-alive not applied: %+v", d)
+	}
+}
+
+func TestTransportConfig_ResponseHeaderTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		w.Write([]byte("late"))
+	}))
+	defer ts.Close()
+
+	c := NewHttpClientWithTransport(ts.URL, &TransportConfig{ResponseHeaderTimeout: 50 * time.Millisecond})
+	if _, err := c.DoGet("/"); err == nil {
+		t.Fatal("expected response header timeout")
+	}
+}
+
+func TestTransportConfig_JA3HandshakeTimeout(t *testing.T) {
+	// 只接受 TCP 连接、从不回应 ClientHello 的服务端
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	c := NewHttpClientWithTransport("https://"+ln.Addr().String(), &TransportConfig{TLSHandshakeTimeout: 100 * time.Millisecond})
+	_ = c.EnableJA3("chrome")
+	start := time.Now()
+	if _, err := c.DoGet("/"); err == nil {
+		t.Fatal("expected handshake timeout")
+	}
+	// 握手超时不可重试，远小于 30s 的请求超时即可
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("handshake timeout not honored, took %v", elapsed)
+	}
+}
+
+func TestTransportConfig_ControlAndNoDelay(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	var calls atomic.Int32
+	c := NewHttpClientWithTransport(ts.URL, &TransportConfig{
+		DisableNoDelay: true,
+		Control: func(network, address string, rc syscall.RawConn) error {
+			calls.Add(1)
+			return nil
+		},
+	})
+	if _, err := c.DoGet("/"); err != nil {
+		t.Fatalf("DoGet failed: %v", err)
+	}
+	if calls.Load() == 0 {
+		t.Fatal("Control hook should be called")
+	}
+
+	c = NewHttpClientWithTransport(ts.URL, &TransportConfig{
+		Control: func(network, address string, rc syscall.RawConn) error {
+			return errors.New("denied by hook")
+		},
+	})
+	if _, err := c.DoGet("/"); err == nil {
+		t.Fatal("Control error should abort the dial")
+	}
+}
+
+func TestTransportConfig_LinuxSocketOptions(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("SO_MARK / TCP Fast Open are Linux only")
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	c := NewHttpClientWithTransport(ts.URL, &TransportConfig{TCPFastOpen: true})
+	if _, err := c.DoGet("/"); err != nil {
+		t.Fatalf("DoGet with TCP Fast Open failed: %v", err)
+	}
+	c = NewHttpClientWithTransport(ts.URL, &TransportConfig{SocketMark: 0x100})
+	if _, err := c.DoGet("/"); err != nil {
+		if errors.Is(err, syscall.EPERM) {
+			t.Skip("SO_MARK requires CAP_NET_ADMIN")
+		}
+		t.Fatalf("DoGet with SO_MARK failed: %v", err)
+	}
+}
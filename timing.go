@@ -0,0 +1,180 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Timing 请求各阶段耗时，重试时各阶段为最后一次尝试的值。
+// 阶段未发生（如复用连接时的 DNS/建连/TLS）时对应字段为 0。
+type Timing struct {
+	DNS          time.Duration // DNS 解析
+	Connect      time.Duration // TCP 建连（经 HTTP 代理时为连到代理）
+	ProxyConnect time.Duration // HTTP 代理 CONNECT 隧道建立
+	TLSHandshake time.Duration // TLS 握手，含 JA3（uTLS）握手
+	TTFB         time.Duration // 从开始获取连接到收到响应首字节
+	BodyTransfer time.Duration // 从收到首字节到响应体读取完毕
+	Total        time.Duration // 整个请求耗时（含重试，不含并发限速排队）
+	Reused       bool          // 是否复用了连接池中的连接
+	Attempts     int           // 实际发送次数（1 表示未重试，重定向不计入）
+}
+
+// logFields 返回用于日志的 key/value 对。
+func (t *Timing) logFields() []interface{} {
+	return []interface{}{
+		"time_dns", t.DNS.String(),
+		"time_connect", t.Connect.String(),
+		"time_proxy_connect", t.ProxyConnect.String(),
+		"time_tls", t.TLSHandshake.String(),
+		"time_ttfb", t.TTFB.String(),
+		"time_transfer", t.BodyTransfer.String(),
+		"time_total", t.Total.String(),
+		"conn_reused", t.Reused,
+	}
+}
+
+// SetTimingLogging 开启后在请求日志中输出各阶段耗时。
+func (h *HttpClient) SetTimingLogging(enable bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.logTiming = enable
+}
+
+// timingKey 在 context 中携带 timingTrace，供 OnProxyConnectResponse 使用。
+type timingKey struct{}
+
+// timingTrace 通过 httptrace 钩子采集耗时。钩子可能在拨号 goroutine 中回调，需加锁。
+type timingTrace struct {
+	mu        sync.Mutex
+	start     time.Time
+	t         Timing
+	getConn   time.Time
+	dnsStart  time.Time
+	connStart time.Time
+	connDone  time.Time
+	tlsStart  time.Time
+	firstByte time.Time
+	parent    *timingTrace // 外层采集（如 HAR 的单次往返计时嵌套在 execute 的计时内）
+}
+
+func newTimingTrace() *timingTrace {
+	return &timingTrace{start: time.Now()}
+}
+
+// withContext 将采集钩子挂到 ctx 上。
+func (tt *timingTrace) withContext(ctx context.Context) context.Context {
+	tt.parent, _ = ctx.Value(timingKey{}).(*timingTrace)
+	ctx = context.WithValue(ctx, timingKey{}, tt)
+	return httptrace.WithClientTrace(ctx, tt.trace())
+}
+
+func (tt *timingTrace) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			tt.mu.Lock()
+			defer tt.mu.Unlock()
+			// 每次获取连接（重试或重定向的下一跳）重新计时
+			tt.t = Timing{Attempts: tt.t.Attempts}
+			tt.getConn = time.Now()
+			tt.connStart, tt.connDone, tt.firstByte = time.Time{}, time.Time{}, time.Time{}
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			tt.mu.Lock()
+			tt.dnsStart = time.Now()
+			tt.mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			tt.mu.Lock()
+			if !tt.dnsStart.IsZero() {
+				tt.t.DNS = time.Since(tt.dnsStart)
+			}
+			tt.mu.Unlock()
+		},
+		ConnectStart: func(string, string) {
+			tt.mu.Lock()
+			// 多地址并行拨号时以最早的开始时间为准
+			if tt.connStart.IsZero() {
+				tt.connStart = time.Now()
+			}
+			tt.mu.Unlock()
+		},
+		ConnectDone: func(_, _ string, err error) {
+			tt.mu.Lock()
+			if err == nil && !tt.connStart.IsZero() {
+				tt.connDone = time.Now()
+				tt.t.Connect = tt.connDone.Sub(tt.connStart)
+			}
+			tt.mu.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			tt.mu.Lock()
+			tt.tlsStart = time.Now()
+			tt.mu.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			tt.mu.Lock()
+			if !tt.tlsStart.IsZero() {
+				tt.t.TLSHandshake = time.Since(tt.tlsStart)
+			}
+			tt.mu.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			tt.mu.Lock()
+			tt.t.Reused = info.Reused
+			tt.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			tt.mu.Lock()
+			tt.firstByte = time.Now()
+			if !tt.getConn.IsZero() {
+				tt.t.TTFB = tt.firstByte.Sub(tt.getConn)
+			}
+			tt.mu.Unlock()
+		},
+	}
+}
+
+// setAttempt 记录当前为第 n 次发送，由重试循环调用。
+func (tt *timingTrace) setAttempt(n int) {
+	tt.mu.Lock()
+	tt.t.Attempts = n
+	tt.mu.Unlock()
+}
+
+// proxyConnected 记录 CONNECT 隧道建立完成，并通知外层采集。
+func (tt *timingTrace) proxyConnected() {
+	tt.mu.Lock()
+	if !tt.connDone.IsZero() {
+		tt.t.ProxyConnect = time.Since(tt.connDone)
+	}
+	tt.mu.Unlock()
+	if tt.parent != nil {
+		tt.parent.proxyConnected()
+	}
+}
+
+// finish 在响应体读取完毕后结束计时并返回结果。
+func (tt *timingTrace) finish() *Timing {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	now := time.Now()
+	t := tt.t
+	if !tt.firstByte.IsZero() {
+		t.BodyTransfer = now.Sub(tt.firstByte)
+	}
+	t.Total = now.Sub(tt.start)
+	return &t
+}
+
+// onProxyConnectResponse 作为 transport.OnProxyConnectResponse，记录 CONNECT 耗时。
+func onProxyConnectResponse(ctx context.Context, _ *url.URL, _ *http.Request, _ *http.Response) error {
+	if tt, ok := ctx.Value(timingKey{}).(*timingTrace); ok {
+		tt.proxyConnected()
+	}
+	return nil
+}
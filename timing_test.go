@@ -0,0 +1,154 @@
+package client
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// startConnectProxy 启动一个只支持 CONNECT 的 HTTP 代理。
+func startConnectProxy(t *testing.T) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "CONNECT only", http.StatusMethodNotAllowed)
+			return
+		}
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			upstream.Close()
+			return
+		}
+		go func() {
+			io.Copy(upstream, conn)
+			upstream.Close()
+		}()
+		io.Copy(conn, upstream)
+		conn.Close()
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestTiming_Phases(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte("head"))
+		w.(http.Flusher).Flush()
+		time.Sleep(30 * time.Millisecond)
+		w.Write([]byte("tail"))
+	}))
+	defer ts.Close()
+
+	c := NewHttpClient(ts.URL)
+	resp, err := c.Do(&Request{Path: "/"})
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	tm := resp.Timing
+	if tm.Connect <= 0 || tm.Reused || tm.Attempts != 1 {
+		t.Fatalf("first request should dial a new connection: %+v", tm)
+	}
+	if tm.TTFB < 50*time.Millisecond || tm.BodyTransfer < 30*time.Millisecond || tm.Total < tm.TTFB+tm.BodyTransfer {
+		t.Fatalf("unexpected TTFB/transfer: %+v", tm)
+	}
+
+	resp, err = c.Do(&Request{Path: "/"})
+	if err != nil {
+		t.Fatalf("second Do failed: %v", err)
+	}
+	if !resp.Timing.Reused || resp.Timing.Connect != 0 {
+		t.Fatalf("second request should reuse the connection: %+v", resp.Timing)
+	}
+}
+
+func TestTiming_AttemptsIgnoreRedirects(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/a" {
+			http.Redirect(w, r, "/b", http.StatusFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	c := NewHttpClient(ts.URL)
+	resp, err := c.Do(&Request{Path: "/a"})
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if resp.Timing.Attempts != 1 {
+		t.Fatalf("a redirect is not a retry, got Attempts=%d", resp.Timing.Attempts)
+	}
+}
+
+func TestTiming_TLSAndDNS(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+	_, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+
+	for _, profile := range tlsProfiles {
+		c := NewHttpClientWithTransport("https://example.com:"+port, &TransportConfig{Resolver: &countingResolver{}})
+		trustServer(t, c, ts, profile)
+		resp, err := c.Do(&Request{Path: "/"})
+		if err != nil {
+			t.Fatalf("[%s] Do failed: %v", profile, err)
+		}
+		if resp.Timing.TLSHandshake <= 0 || resp.Timing.DNS <= 0 {
+			t.Fatalf("[%s] TLS/DNS timing missing: %+v", profile, resp.Timing)
+		}
+	}
+}
+
+func TestTiming_ProxyConnect(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+	proxy := startConnectProxy(t)
+
+	c := NewHttpClient(ts.URL)
+	trustServer(t, c, ts, "")
+	if err := c.SetProxy(&ProxyConfig{Type: "http", Address: proxy.Listener.Addr().String()}); err != nil {
+		t.Fatalf("SetProxy failed: %v", err)
+	}
+	resp, err := c.Do(&Request{Path: "/"})
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if resp.Timing.ProxyConnect <= 0 || resp.Timing.TLSHandshake <= 0 {
+		t.Fatalf("proxy CONNECT timing missing: %+v", resp.Timing)
+	}
+}
+
+func TestTiming_Logging(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	core, logs := observer.New(zap.InfoLevel)
+	c := NewHttpClient(ts.URL)
+	c.SetLogger(zap.New(core).Sugar())
+	c.SetTimingLogging(true)
+	if _, err := c.DoGet("/"); err != nil {
+		t.Fatalf("DoGet failed: %v", err)
+	}
+	entries := logs.FilterMessage("请求成功").All()
+	if len(entries) == 0 || entries[0].ContextMap()["time_total"] == nil {
+		t.Fatal("timing should be logged when enabled")
+	}
+}
@@ -0,0 +1,195 @@
+package client
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+
+	utls "github.com/refraction-networking/utls"
+)
+
+// ErrPinMismatch 服务端证书链中没有任何公钥命中 SPKI 固定列表。
+var ErrPinMismatch = errors.New("tls: server certificate does not match any pinned SPKI")
+
+// SetTLSConfig 设置 TLS 配置（nil 表示恢复默认），同时作用于标准 TLS 与 JA3（uTLS）握手。
+func (h *HttpClient) SetTLSConfig(cfg *TLSConfig) error {
+	var tc *tls.Config
+	if cfg != nil {
+		var err error
+		if tc, err = buildTLSConfig(cfg); err != nil {
+			return err
+		}
+		h.transport.TLSClientConfig = tc.Clone()
+	} else {
+		h.transport.TLSClientConfig = nil
+	}
+	// 先更新 transport 再递增版本，Session 独占的连接池据此重建
+	h.mu.Lock()
+	h.tlsConfig = tc
+	h.dialGen++
+	h.mu.Unlock()
+	// 池中的连接按旧配置握手，新的 CA、固定公钥与客户端证书须在新连接上生效
+	h.transport.CloseIdleConnections()
+	return nil
+}
+
+// buildTLSConfig 加载证书/CA 并生成 *tls.Config。
+func buildTLSConfig(cfg *TLSConfig) (*tls.Config, error) {
+	tc := &tls.Config{
+		ServerName:         cfg.ServerName,
+		MinVersion:         cfg.MinVersion,
+		MaxVersion:         cfg.MaxVersion,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+	tc.Certificates = append(tc.Certificates, cfg.Certificates...)
+	for _, pair := range cfg.ClientCerts {
+		cert, err := tls.LoadX509KeyPair(pair.CertFile, pair.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate %s: %w", pair.CertFile, err)
+		}
+		tc.Certificates = append(tc.Certificates, cert)
+	}
+
+	if len(cfg.RootCAFiles) > 0 || len(cfg.RootCAPEM) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		for _, file := range cfg.RootCAFiles {
+			pem, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CA file %s: %w", file, err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no valid certificate found in CA file %s", file)
+			}
+		}
+		if len(cfg.RootCAPEM) > 0 && !pool.AppendCertsFromPEM(cfg.RootCAPEM) {
+			return nil, errors.New("no valid certificate found in RootCAPEM")
+		}
+		tc.RootCAs = pool
+	}
+
+	if len(cfg.PinnedSPKI) > 0 {
+		pins := make(map[string]bool, len(cfg.PinnedSPKI))
+		for _, p := range cfg.PinnedSPKI {
+			pins[p] = true
+		}
+		tc.VerifyConnection = func(cs tls.ConnectionState) error {
+			return verifyPins(pins, cs.PeerCertificates)
+		}
+	}
+	return tc, nil
+}
+
+// verifyPins 校验证书链中是否存在命中固定列表的公钥。
+func verifyPins(pins map[string]bool, certs []*x509.Certificate) error {
+	for _, cert := range certs {
+		if pins[SPKIHash(cert)] {
+			return nil
+		}
+	}
+	return ErrPinMismatch
+}
+
+// SPKIHash 返回证书公钥的 base64(SHA-256(SubjectPublicKeyInfo))，用于 TLSConfig.PinnedSPKI。
+func SPKIHash(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// utlsConfig 根据当前 TLS 配置生成 uTLS 握手使用的配置。
+func (h *HttpClient) utlsConfig(host string) *utls.Config {
+	h.mu.RLock()
+	tc := h.tlsConfig
+	h.mu.RUnlock()
+	if tc == nil {
+		return &utls.Config{ServerName: host}
+	}
+	uc := &utls.Config{
+		ServerName:         host,
+		RootCAs:            tc.RootCAs,
+		MinVersion:         tc.MinVersion,
+		MaxVersion:         tc.MaxVersion,
+		InsecureSkipVerify: tc.InsecureSkipVerify,
+	}
+	if tc.ServerName != "" {
+		uc.ServerName = tc.ServerName
+	}
+	for _, cert := range tc.Certificates {
+		uc.Certificates = append(uc.Certificates, toUTLSCertificate(cert))
+	}
+	if verify := tc.VerifyConnection; verify != nil {
+		uc.VerifyConnection = func(cs utls.ConnectionState) error {
+			return verify(tls.ConnectionState{PeerCertificates: cs.PeerCertificates})
+		}
+	}
+	return uc
+}
+
+// filterTLSVersions 去掉 supported_versions 中超出 [minV, maxV] 的版本（0 表示不限），GREASE 值保留。
+func filterTLSVersions(versions []uint16, minV, maxV uint16) []uint16 {
+	out := versions[:0:0]
+	for _, v := range versions {
+		if isGREASE(v) || checkTLSVersion(v, minV, maxV) == nil {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// hasTLSVersion 是否包含至少一个非 GREASE 版本。
+func hasTLSVersion(versions []uint16) bool {
+	for _, v := range versions {
+		if !isGREASE(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTLSVersion 校验协商出的版本是否在配置范围内。
+func checkTLSVersion(v, minV, maxV uint16) error {
+	if (minV != 0 && v < minV) || (maxV != 0 && v > maxV) {
+		return fmt.Errorf("tls: negotiated %s outside the configured version range", tls.VersionName(v))
+	}
+	return nil
+}
+
+// isGREASE 判断是否为 RFC 8701 的 GREASE 值（0x0a0a、0x1a1a……）。
+func isGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+// toUTLSCertificate 将 tls.Certificate 转换为 uTLS 的同构类型。
+func toUTLSCertificate(cert tls.Certificate) utls.Certificate {
+	uc := utls.Certificate{
+		Certificate:                 cert.Certificate,
+		PrivateKey:                  cert.PrivateKey,
+		OCSPStaple:                  cert.OCSPStaple,
+		SignedCertificateTimestamps: cert.SignedCertificateTimestamps,
+		Leaf:                        cert.Leaf,
+	}
+	for _, s := range cert.SupportedSignatureAlgorithms {
+		uc.SupportedSignatureAlgorithms = append(uc.SupportedSignatureAlgorithms, utls.SignatureScheme(s))
+	}
+	return uc
+}
+
+// stdTLSState 将 uTLS 连接状态转换为标准库类型，供 httptrace 与连接信息使用。
+func stdTLSState(st utls.ConnectionState) tls.ConnectionState {
+	return tls.ConnectionState{
+		Version:            st.Version,
+		HandshakeComplete:  st.HandshakeComplete,
+		DidResume:          st.DidResume,
+		CipherSuite:        st.CipherSuite,
+		NegotiatedProtocol: st.NegotiatedProtocol,
+		ServerName:         st.ServerName,
+		PeerCertificates:   st.PeerCertificates,
+		VerifiedChains:     st.VerifiedChains,
+	}
+}
@@ -0,0 +1,308 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// serverCAPEM 返回 httptest TLS 服务端证书的 PEM。
+func serverCAPEM(ts *httptest.Server) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+}
+
+// tlsProfiles 标准 TLS 与 JA3（uTLS）两条握手路径，需同时覆盖两者的测试遍历它。
+var tlsProfiles = []string{"", "chrome"}
+
+// trustServer 让 c 信任 httptest TLS 服务端证书，并按 profile 开启 JA3（空表示标准 TLS）。
+func trustServer(t *testing.T, c *HttpClient, ts *httptest.Server, profile string) {
+	t.Helper()
+	if err := c.SetTLSConfig(&TLSConfig{RootCAPEM: serverCAPEM(ts)}); err != nil {
+		t.Fatalf("SetTLSConfig failed: %v", err)
+	}
+	if err := c.EnableJA3(profile); err != nil {
+		t.Fatalf("EnableJA3(%q) failed: %v", profile, err)
+	}
+}
+
+// selfSignedClientCert 生成一个用于 mTLS 测试的客户端证书。
+func selfSignedClientCert(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestSetTLSConfig_RootCA(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("tls ok"))
+	}))
+	defer ts.Close()
+
+	for _, profile := range tlsProfiles {
+		c := NewHttpClient(ts.URL)
+		if err := c.EnableJA3(profile); err != nil {
+			t.Fatalf("EnableJA3(%q) failed: %v", profile, err)
+		}
+		if _, err := c.DoGet("/"); err == nil {
+			t.Fatalf("[%s] expected verification error without private CA", profile)
+		}
+		if err := c.SetTLSConfig(&TLSConfig{RootCAPEM: serverCAPEM(ts)}); err != nil {
+			t.Fatalf("SetTLSConfig failed: %v", err)
+		}
+		body, err := c.DoGet("/")
+		if err != nil {
+			t.Fatalf("[%s] DoGet failed: %v", profile, err)
+		}
+		if string(body) != "tls ok" {
+			t.Fatalf("[%s] unexpected body: %s", profile, body)
+		}
+	}
+}
+
+func TestSetTLSConfig_VersionRange(t *testing.T) {
+	tls12 := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	tls12.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	tls12.StartTLS()
+	defer tls12.Close()
+	tls13 := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer tls13.Close()
+
+	for _, profile := range tlsProfiles {
+		for _, tc := range []struct {
+			ts       *httptest.Server
+			min, max uint16
+			want     uint16 // 0 表示握手应失败
+		}{
+			{tls12, tls.VersionTLS13, 0, 0},
+			{tls13, 0, tls.VersionTLS12, tls.VersionTLS12},
+			{tls13, tls.VersionTLS13, 0, tls.VersionTLS13},
+		} {
+			c := NewHttpClient(tc.ts.URL)
+			trustServer(t, c, tc.ts, profile)
+			if err := c.SetTLSConfig(&TLSConfig{RootCAPEM: serverCAPEM(tc.ts), MinVersion: tc.min, MaxVersion: tc.max}); err != nil {
+				t.Fatalf("SetTLSConfig failed: %v", err)
+			}
+			resp, err := c.Do(&Request{Path: "/"})
+			switch {
+			case tc.want == 0 && err == nil:
+				t.Errorf("[%s] min=%x max=%x: handshake outside the range should fail", profile, tc.min, tc.max)
+			case tc.want != 0 && err != nil:
+				t.Errorf("[%s] min=%x max=%x: %v", profile, tc.min, tc.max, err)
+			case tc.want != 0 && resp.Conn.TLSVersion != tc.want:
+				t.Errorf("[%s] min=%x max=%x: negotiated %s", profile, tc.min, tc.max, resp.Conn.TLSVersionName())
+			}
+		}
+	}
+}
+
+func TestSetTLSConfig_ClosesIdleConnections(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	for _, profile := range tlsProfiles {
+		c := NewHttpClient(ts.URL)
+		trustServer(t, c, ts, profile)
+		if _, err := c.DoGet("/"); err != nil {
+			t.Fatalf("[%s] DoGet failed: %v", profile, err)
+		}
+		// 新的固定公钥不匹配，池中已建立的连接不应再被复用
+		if err := c.SetTLSConfig(&TLSConfig{RootCAPEM: serverCAPEM(ts), PinnedSPKI: []string{"AAAA"}}); err != nil {
+			t.Fatalf("SetTLSConfig failed: %v", err)
+		}
+		if _, err := c.DoGet("/"); err == nil {
+			t.Fatalf("[%s] pooled connection bypassed the new pin", profile)
+		}
+	}
+}
+
+func TestSetTLSConfig_PinnedSPKI(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("pinned"))
+	}))
+	defer ts.Close()
+	pin := SPKIHash(ts.Certificate())
+
+	for _, profile := range []string{"", "firefox"} {
+		c := NewHttpClient(ts.URL)
+		_ = c.EnableJA3(profile)
+		_ = c.SetTLSConfig(&TLSConfig{InsecureSkipVerify: true, PinnedSPKI: []string{"bm90LWEtcGlu"}})
+		if _, err := c.DoGet("/"); err == nil {
+			t.Fatalf("[%s] expected pin mismatch", profile)
+		}
+		_ = c.SetTLSConfig(&TLSConfig{InsecureSkipVerify: true, PinnedSPKI: []string{pin}})
+		if _, err := c.DoGet("/"); err != nil {
+			t.Fatalf("[%s] matching pin should succeed: %v", profile, err)
+		}
+	}
+}
+
+func TestSetTLSConfig_ClientCertificate(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			t.Errorf("expected client certificate")
+		}
+		w.Write([]byte("mtls ok"))
+	}))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	ts.StartTLS()
+	defer ts.Close()
+
+	cert := selfSignedClientCert(t)
+	for _, profile := range tlsProfiles {
+		c := NewHttpClient(ts.URL)
+		_ = c.EnableJA3(profile)
+		err := c.SetTLSConfig(&TLSConfig{
+			RootCAPEM:    serverCAPEM(ts),
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		})
+		if err != nil {
+			t.Fatalf("SetTLSConfig failed: %v", err)
+		}
+		body, err := c.DoGet("/")
+		if err != nil {
+			t.Fatalf("[%s] mTLS request failed: %v", profile, err)
+		}
+		if string(body) != "mtls ok" {
+			t.Fatalf("[%s] unexpected body: %s", profile, body)
+		}
+	}
+}
+
+func TestSetTLSConfig_InvalidFiles(t *testing.T) {
+	c := NewHttpClient("https://example.com")
+	if err := c.SetTLSConfig(&TLSConfig{RootCAFiles: []string{"missing-ca.pem"}}); err == nil {
+		t.Fatal("expected error for missing CA file")
+	}
+	if err := c.SetTLSConfig(&TLSConfig{ClientCerts: []CertKeyPair{{CertFile: "a.pem", KeyFile: "b.pem"}}}); err == nil {
+		t.Fatal("expected error for missing client certificate")
+	}
+	// CloseIdleConnections 会触发 transport 的 HTTP/2 初始化并填入仅含 NextProtos 的默认配置
+	if err := c.SetTLSConfig(nil); err != nil || c.tlsConfig != nil {
+		t.Fatal("SetTLSConfig(nil) should reset TLS config")
+	}
+	if tc := c.transport.TLSClientConfig; tc != nil && (tc.RootCAs != nil || len(tc.Certificates) > 0) {
+		t.Fatal("SetTLSConfig(nil) should drop transport TLS settings")
+	}
+}
+
+// resumeServer 启动一个可限定最高 TLS 版本的 httptest TLS 服务端。
+func resumeServer(maxVersion uint16) *httptest.Server {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	ts.TLS = &tls.Config{MaxVersion: maxVersion}
+	ts.StartTLS()
+	return ts
+}
+
+// doTwiceFresh 连续发送两次请求，中间关闭空闲连接以强制重新握手，返回第二次的连接信息。
+func doTwiceFresh(t *testing.T, c *HttpClient, s *Session) *ConnInfo {
+	var last *ConnInfo
+	for i := 0; i < 2; i++ {
+		resp, err := c.Do(&Request{Path: "/", Session: s})
+		if err != nil {
+			t.Fatalf("Do #%d failed: %v", i, err)
+		}
+		last = resp.Conn
+		c.Close()
+		if s != nil {
+			s.CloseIdleConnections()
+		}
+	}
+	return last
+}
+
+func TestTLSSessionCache_Resumes(t *testing.T) {
+	cases := []struct {
+		profile    string
+		maxVersion uint16
+	}{
+		{"chrome", tls.VersionTLS12},
+		{"chrome-psk", tls.VersionTLS13},
+	}
+	for _, tc := range cases {
+		ts := resumeServer(tc.maxVersion)
+		c := NewHttpClient(ts.URL)
+		trustServer(t, c, ts, tc.profile)
+		if ci := doTwiceFresh(t, c, nil); !ci.DidResume {
+			t.Errorf("[%s] second handshake should resume, got %+v", tc.profile, ci)
+		}
+		ts.Close()
+	}
+}
+
+func TestTLSSessionCache_Disabled(t *testing.T) {
+	ts := resumeServer(tls.VersionTLS12)
+	defer ts.Close()
+
+	c := NewHttpClientWithTransport(ts.URL, &TransportConfig{TLSSessionCacheSize: -1})
+	trustServer(t, c, ts, "chrome")
+	if ci := doTwiceFresh(t, c, nil); ci.DidResume {
+		t.Fatal("resumption should not happen with cache disabled")
+	}
+}
+
+func TestTLSSessionCache_PerProfile(t *testing.T) {
+	ts := resumeServer(tls.VersionTLS12)
+	defer ts.Close()
+
+	c := NewHttpClient(ts.URL)
+	for i, tc := range []struct {
+		profile string
+		resume  bool
+	}{
+		{"chrome", false},
+		{"firefox", false}, // 不应携带 chrome 的票据
+		{"chrome", true},
+		{"firefox", true},
+	} {
+		trustServer(t, c, ts, tc.profile)
+		resp, err := c.Do(&Request{Path: "/"})
+		if err != nil {
+			t.Fatalf("#%d Do failed: %v", i, err)
+		}
+		if resp.Conn.DidResume != tc.resume {
+			t.Errorf("#%d [%s] DidResume = %v, want %v", i, tc.profile, resp.Conn.DidResume, tc.resume)
+		}
+		c.Close()
+	}
+}
+
+func TestTLSSessionCache_PerSession(t *testing.T) {
+	ts := resumeServer(tls.VersionTLS12)
+	defer ts.Close()
+
+	c := NewHttpClientWithTransport(ts.URL, &TransportConfig{TLSSessionCacheSize: -1})
+	trustServer(t, c, ts, "chrome")
+	s := NewSession()
+	s.EnableTLSSessionCache(16)
+	if c.transportFor(s) == c.transport {
+		t.Fatal("session with its own cache should use a dedicated transport")
+	}
+	if ci := doTwiceFresh(t, c, s); !ci.DidResume {
+		t.Fatal("session cache should enable resumption")
+	}
+}
@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// 分布式追踪。
+//
+// 设置 Tracer 后，每次逻辑请求（send）产生一个 span，每次实际发送（含重试）在其下产生一个子 span；
+// 请求头 traceparent/tracestate 按 W3C Trace Context 从当前 span 注入。
+// 未设置 Tracer 时，若调用方 ctx 中带有 SpanContext（见 ContextWithSpanContext），则原样透传。
+// 调用方已显式设置 traceparent 请求头时不覆盖。
+// OpenTelemetry 适配见子包 oteltrace。
+
+// Span 属性名，遵循 OpenTelemetry HTTP 语义约定。
+const (
+	AttrHTTPMethod     = "http.request.method"
+	AttrURL            = "url.full"
+	AttrServerAddress  = "server.address"
+	AttrHTTPStatusCode = "http.response.status_code"
+	AttrResendCount    = "http.request.resend_count"
+	AttrErrorType      = "error.type"
+)
+
+// Tracer 分布式追踪接口，实现需并发安全。
+type Tracer interface {
+	// Start 以 ctx 中的 span 为父开始一个客户端 span，返回携带新 span 的 ctx。
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span 一个追踪区间。
+type Span interface {
+	// SetAttributes 设置属性，kv 为 key/value 交替的列表（key 为 string）。
+	SetAttributes(kv ...interface{})
+	// SpanContext 返回用于 traceparent 注入的上下文。
+	SpanContext() SpanContext
+	// End 结束 span，err 非 nil 表示请求失败。
+	End(err error)
+}
+
+// SpanContext W3C Trace Context 的传播字段。
+type SpanContext struct {
+	TraceID    [16]byte
+	SpanID     [8]byte
+	Flags      byte   // trace-flags，01 表示采样
+	TraceState string // tracestate 头原文
+}
+
+// IsValid trace-id 与 parent-id 均非全零时有效。
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// TraceParent 返回 traceparent 头的值（version 00）。
+func (sc SpanContext) TraceParent() string {
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" + hex.EncodeToString(sc.SpanID[:]) + "-" + hex.EncodeToString([]byte{sc.Flags})
+}
+
+// ParseTraceParent 解析 traceparent/tracestate 头，可用于从入站请求继续追踪。
+func ParseTraceParent(traceparent, tracestate string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, errors.New("无效的 traceparent")
+	}
+	// version ff 非法；version 00 不允许额外字段
+	if parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return sc, errors.New("无效的 traceparent")
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, errors.New("无效的 traceparent")
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, errors.New("无效的 traceparent")
+	}
+	flags, err := strconv.ParseUint(parts[3], 16, 8)
+	if err != nil || strings.ToLower(traceparent) != traceparent {
+		return sc, errors.New("无效的 traceparent")
+	}
+	sc.Flags = byte(flags)
+	if !sc.IsValid() {
+		return sc, errors.New("无效的 traceparent")
+	}
+	sc.TraceState = strings.TrimSpace(tracestate)
+	return sc, nil
+}
+
+// spanContextKey context 中的 SpanContext。
+type spanContextKey struct{}
+
+// ContextWithSpanContext 返回携带 sc 的 ctx，未设置 Tracer 时请求会透传其 traceparent。
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext 取出 ContextWithSpanContext 设置的 SpanContext。
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok && sc.IsValid()
+}
+
+// SetTracer 设置分布式追踪实现，nil 表示关闭（仍透传调用方 ctx 中的 SpanContext）。
+func (h *HttpClient) SetTracer(t Tracer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.tracer = t
+}
+
+// getTracer 返回当前 Tracer。
+func (h *HttpClient) getTracer() Tracer {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.tracer
+}
+
+// startSpan 为 req 开始一个 span 并设置请求属性（URL 经 rd 脱敏），返回绑定新 ctx 的请求副本。
+func startSpan(t Tracer, rd *redactor, req *http.Request, name string) (*http.Request, Span) {
+	ctx, span := t.Start(req.Context(), name)
+	span.SetAttributes(
+		AttrHTTPMethod, req.Method,
+		AttrURL, rd.url(req.URL.String()),
+		AttrServerAddress, req.URL.Hostname(),
+	)
+	return req.WithContext(ctx), span
+}
+
+// endSpan 记录响应状态并结束 span。
+func endSpan(span Span, status int, err error) {
+	if status > 0 {
+		span.SetAttributes(AttrHTTPStatusCode, status)
+		if status >= 400 {
+			span.SetAttributes(AttrErrorType, strconv.Itoa(status))
+		}
+	}
+	span.End(err)
+}
+
+// injectTraceContext 将 span（nil 时取 ctx 中的 SpanContext）写入 traceparent/tracestate 头。
+func injectTraceContext(req *http.Request, span Span) {
+	var sc SpanContext
+	if span != nil {
+		sc = span.SpanContext()
+	} else if v, ok := SpanContextFromContext(req.Context()); ok {
+		sc = v
+	}
+	if !sc.IsValid() {
+		return
+	}
+	req.Header.Set("traceparent", sc.TraceParent())
+	if sc.TraceState != "" {
+		req.Header.Set("tracestate", sc.TraceState)
+	} else {
+		req.Header.Del("tracestate")
+	}
+}
@@ -0,0 +1,193 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// recordTracer 记录 span 的测试 Tracer。
+type recordTracer struct {
+	mu     sync.Mutex
+	spans  []*recordSpan
+	nextID atomic.Uint32
+}
+
+type recordSpan struct {
+	name   string
+	parent *recordSpan
+	sc     SpanContext
+	attrs  map[string]interface{}
+	err    error
+	ended  bool
+}
+
+type recordSpanKey struct{}
+
+func (t *recordTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	s := &recordSpan{name: name, attrs: make(map[string]interface{})}
+	s.sc.TraceID = [16]byte{0xab, 1}
+	s.sc.SpanID = [8]byte{0xcd, byte(t.nextID.Add(1))}
+	s.sc.Flags = 1
+	s.sc.TraceState = "vendor=1"
+	if p, ok := ctx.Value(recordSpanKey{}).(*recordSpan); ok {
+		s.parent = p
+	}
+	t.mu.Lock()
+	t.spans = append(t.spans, s)
+	t.mu.Unlock()
+	return context.WithValue(ctx, recordSpanKey{}, s), s
+}
+
+func (s *recordSpan) SetAttributes(kv ...interface{}) {
+	for i := 0; i+1 < len(kv); i += 2 {
+		s.attrs[kv[i].(string)] = kv[i+1]
+	}
+}
+
+func (s *recordSpan) SpanContext() SpanContext { return s.sc }
+
+func (s *recordSpan) End(err error) {
+	s.err = err
+	s.ended = true
+}
+
+func TestTracer_SpansPerRequestAndAttempt(t *testing.T) {
+	var (
+		calls       atomic.Int32
+		traceparent atomic.Value
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			// 第一次直接断开，触发重试
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		traceparent.Store(r.Header.Get("traceparent") + " " + r.Header.Get("tracestate"))
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	tr := &recordTracer{}
+	c := NewHttpClient(ts.URL)
+	c.SetTracer(tr)
+	resp, err := c.Do(&Request{Path: "/items?id=1"})
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	}
+
+	if len(tr.spans) != 3 {
+		t.Fatalf("expected 1 request span + 2 attempt spans, got %d", len(tr.spans))
+	}
+	root, first, second := tr.spans[0], tr.spans[1], tr.spans[2]
+	if root.name != "HTTP GET" || first.name != "HTTP GET attempt" {
+		t.Fatalf("unexpected span names: %q %q", root.name, first.name)
+	}
+	for _, s := range tr.spans {
+		if !s.ended {
+			t.Fatalf("span %q not ended", s.name)
+		}
+		if s.attrs[AttrHTTPMethod] != "GET" || s.attrs[AttrURL] != ts.URL+"/items?id=1" {
+			t.Fatalf("unexpected attrs on %q: %v", s.name, s.attrs)
+		}
+	}
+	if first.parent != root || second.parent != root {
+		t.Fatal("attempt spans should be children of the request span")
+	}
+	if first.err == nil || second.err != nil {
+		t.Fatalf("unexpected attempt errors: %v / %v", first.err, second.err)
+	}
+	if first.attrs[AttrResendCount] != 0 || second.attrs[AttrResendCount] != 1 {
+		t.Fatalf("unexpected resend counts: %v", second.attrs)
+	}
+	if root.attrs[AttrHTTPStatusCode] != http.StatusNotFound || root.attrs[AttrErrorType] != "404" {
+		t.Fatalf("unexpected request span attrs: %v", root.attrs)
+	}
+	if got, want := traceparent.Load(), second.sc.TraceParent()+" vendor=1"; got != want {
+		t.Fatalf("traceparent = %q, want %q", got, want)
+	}
+}
+
+func TestTracer_RedactsURL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	tr := &recordTracer{}
+	c := NewHttpClient(ts.URL)
+	c.SetTracer(tr)
+	if _, err := c.Do(&Request{Path: "/items?id=1&access_token=s3cret"}); err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	want := ts.URL + "/items?id=1&access_token=" + DefaultRedactReplacement
+	for _, s := range tr.spans {
+		if s.attrs[AttrURL] != want {
+			t.Fatalf("%q url.full = %v, want %s", s.name, s.attrs[AttrURL], want)
+		}
+	}
+}
+
+func TestTracer_PropagatesCallerContext(t *testing.T) {
+	var got atomic.Value
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.Store(r.Header.Get("traceparent") + " " + r.Header.Get("tracestate"))
+	}))
+	defer ts.Close()
+
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, err := ParseTraceParent(parent, "congo=t61rcWkgMzE")
+	if err != nil {
+		t.Fatalf("ParseTraceParent failed: %v", err)
+	}
+	c := NewHttpClient(ts.URL)
+	ctx := ContextWithSpanContext(context.Background(), sc)
+	if _, err := c.Do(&Request{Context: ctx, Path: "/"}); err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if got.Load() != parent+" congo=t61rcWkgMzE" {
+		t.Fatalf("unexpected propagated headers: %q", got.Load())
+	}
+
+	// 调用方显式设置的 traceparent 不被覆盖
+	c.SetTracer(&recordTracer{})
+	explicit := "00-11111111111111111111111111111111-2222222222222222-00"
+	if _, err := c.Do(&Request{Path: "/", Header: http.Header{"Traceparent": {explicit}}}); err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if got.Load() != explicit+" " {
+		t.Fatalf("explicit traceparent overwritten: %q", got.Load())
+	}
+}
+
+func TestParseTraceParent(t *testing.T) {
+	tests := []struct {
+		in string
+		ok bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		sc, err := ParseTraceParent(tt.in, "")
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseTraceParent(%q) err = %v, want ok=%v", tt.in, err, tt.ok)
+			continue
+		}
+		if tt.ok && tt.in[:2] == "00" && sc.TraceParent() != tt.in {
+			t.Errorf("round trip = %q, want %q", sc.TraceParent(), tt.in)
+		}
+	}
+}
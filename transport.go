@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
 	"time"
 
@@ -16,7 +17,8 @@ import (
 func (h *HttpClient) SetProxy(cfg *ProxyConfig) error {
 	if cfg == nil {
 		h.transport.Proxy = nil
-		h.transport.DialContext = nil
+		h.setProxyDial(nil, false)
+		h.setProxyConfig(nil)
 		return nil
 	}
 
@@ -30,7 +32,7 @@ func (h *HttpClient) SetProxy(cfg *ProxyConfig) error {
 			proxyURL.User = url.UserPassword(cfg.Username, cfg.Password)
 		}
 		h.transport.Proxy = http.ProxyURL(proxyURL)
-		h.transport.DialContext = nil // 确保不再使用 SOCKS5 Dialer
+		h.setProxyDial(nil, true) // 确保不再使用 SOCKS5 Dialer
 	case "socks5":
 		var auth *proxy.Auth
 		if cfg.Username != "" && cfg.Password != "" {
@@ -39,7 +41,8 @@ func (h *HttpClient) SetProxy(cfg *ProxyConfig) error {
 				Password: cfg.Password,
 			}
 		}
-		dialer, err := proxy.SOCKS5("tcp", cfg.Address, auth, proxy.Direct)
+		// 连接代理服务器同样应用超时与 socket 选项
+		dialer, err := proxy.SOCKS5("tcp", cfg.Address, auth, h.newDialer(nil))
 		if err != nil {
 			return err
 		}
@@ -47,81 +50,210 @@ func (h *HttpClient) SetProxy(cfg *ProxyConfig) error {
 		// 优先使用 ContextDialer，使 SOCKS5 握手阶段能被 context 超时/取消，
 		// 避免高并发时 SOCKS5 握手卡死占用并发槽。
 		if cd, ok := dialer.(proxy.ContextDialer); ok {
-			h.transport.DialContext = cd.DialContext
+			h.setProxyDial(cd.DialContext, false)
 		} else {
-			h.transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			h.setProxyDial(func(ctx context.Context, network, addr string) (net.Conn, error) {
 				return dialer.Dial(network, addr)
-			}
+			}, false)
 		}
 	default:
 		return fmt.Errorf("unsupported proxy type: %s", cfg.Type)
 	}
+	h.setProxyConfig(cfg)
 	return nil
 }
 
+// setProxyConfig 记录当前代理配置（副本），供 ToCurl 渲染。
+func (h *HttpClient) setProxyConfig(cfg *ProxyConfig) {
+	if cfg != nil {
+		cp := *cfg
+		cfg = &cp
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.proxyCfg = cfg
+}
+
+// setProxyDial 线程安全地切换代理拨号函数；httpProxy 标记是否启用了 HTTP 代理。
+// 调用前须已更新 transport.Proxy，Session 独占的连接池据此重建。
+func (h *HttpClient) setProxyDial(dial dialFunc, httpProxy bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.proxyDial = dial
+	h.httpProxy = httpProxy
+	h.dialGen++
+}
+
+// dialRaw 建立底层连接：启用 Unix socket 时直接连接 socket；配置了 SOCKS5 时经代理拨号，
+// 否则直连（按 opts 或 client 配置绑定源地址）；配置了 DNS 选项时先在本地解析主机名（SOCKS5 同样生效），
+// 绑定源地址时同样先解析，以便按每个目标 IP 的地址族选择源地址。
+func (h *HttpClient) dialRaw(ctx context.Context, network, addr string, opts connOptions) (net.Conn, error) {
+	if path := h.unixSocketFor(addr); path != "" {
+		return h.dialUnix(ctx, path)
+	}
+	h.mu.RLock()
+	dial := h.proxyDial
+	bindLocal := dial == nil && (h.localAddrs != nil || opts.localAddr != nil)
+	h.mu.RUnlock()
+	if dial == nil {
+		localAddr := opts.localAddr
+		if localAddr == nil {
+			localAddr = h.connLocalAddr
+		}
+		dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			var local net.IP
+			if host, _, err := net.SplitHostPort(addr); err == nil {
+				local = localAddr(host)
+			}
+			return h.dialSocket(ctx, network, addr, local)
+		}
+	}
+	switch {
+	case h.dns != nil:
+		return h.dialResolved(ctx, network, addr, h.dns.lookup, dial)
+	case bindLocal:
+		return h.dialResolved(ctx, network, addr, lookupSystem, dial)
+	}
+	return dial(ctx, network, addr)
+}
+
+// dialContext 作为 transport.DialContext 使用，明文连接上可改写请求头顺序。
+func (h *HttpClient) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	return h.dialContextWith(ctx, network, addr, connOptions{})
+}
+
+// dialContextWith 按指定连接参数建立明文连接。
+func (h *HttpClient) dialContextWith(ctx context.Context, network, addr string, opts connOptions) (net.Conn, error) {
+	conn, err := h.dialRaw(ctx, network, addr, opts)
+	if err != nil {
+		return nil, err
+	}
+	return newOrderedConn(conn), nil
+}
+
 // EnableJA3 开启 JA3 TLS 指纹模拟；profile 为空时等同于 DisableJA3。
-// 支持：chrome、firefox、safari、edge、ios。
-func (h *HttpClient) EnableJA3(profile string) error {
+// 支持：chrome、firefox、safari、edge、ios、random（uTLS 随机化 ClientHello）。
+// 可选 cfg 配置带权重的候选 profile 进行轮换，详见 JA3Config。
+func (h *HttpClient) EnableJA3(profile string, cfg ...*JA3Config) error {
 	if profile == "" {
-		h.transport.DialTLSContext = nil
-		h.client.Transport = h.transport
+		h.DisableJA3()
 		return nil
 	}
-	h.transport.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
-		// 优先使用已配置的代理 Dialer（如 SOCKS5），避免绕过代理直连
-		var rawConn net.Conn
-		var err error
-		if h.transport.DialContext != nil {
-			rawConn, err = h.transport.DialContext(ctx, network, addr)
-		} else {
-			d := &net.Dialer{Timeout: h.client.Timeout}
-			rawConn, err = d.DialContext(ctx, network, addr)
-		}
-		if err != nil {
-			h.LogInfo("DialContext failed", "error", err)
-			return nil, err
+	var pool []JA3Profile
+	rotate := JA3RotateSession
+	if len(cfg) > 0 && cfg[0] != nil {
+		pool = append(pool, cfg[0].Profiles...)
+		switch cfg[0].Rotate {
+		case "", JA3RotateSession:
+		case JA3RotateConnection:
+			rotate = JA3RotateConnection
+		default:
+			return fmt.Errorf("unsupported JA3 rotate mode: %s", cfg[0].Rotate)
 		}
-		host, _, err := net.SplitHostPort(addr)
-		if err != nil {
-			rawConn.Close()
-			h.LogInfo("SplitHostPort failed", "error", err)
-			return nil, fmt.Errorf("invalid addr %s: %v", addr, err)
-		}
-		clientHelloID := getClientHelloID(profile)
-		uConn := utls.UClient(rawConn, &utls.Config{ServerName: host}, clientHelloID)
-		// 提前构建 ClientHello，再直接修改 ALPNExtension，
-		// 确保只声明 http/1.1，阻止服务端协商 h2。
-		// 说明：Config.NextProtos 无法覆盖预设指纹的 ALPN，因为
-		// ALPNExtension.writeToUConn 会反向覆盖 config.NextProtos。
-		// 正确做法：BuildHandshakeState() 之后找到 ALPNExtension 并修改。
-		if err := uConn.BuildHandshakeState(); err != nil {
-			rawConn.Close()
-			h.LogInfo("BuildHandshakeState failed", "error", err)
-			return nil, err
-		}
-		for _, ext := range uConn.Extensions {
-			if alpnExt, ok := ext.(*utls.ALPNExtension); ok {
-				alpnExt.AlpnProtocols = []string{"http/1.1"}
-				break
+	}
+	h.mu.Lock()
+	h.ja3Profile = pickJA3(pool, profile)
+	h.ja3Pool = pool
+	h.ja3Rotate = rotate
+	h.dialGen++
+	h.mu.Unlock()
+	h.transport.DialTLSContext = h.dialTLS
+	return nil
+}
+
+// dialTLS 使用 uTLS 按 client 级别的 JA3 profile 完成 TLS 握手。
+func (h *HttpClient) dialTLS(ctx context.Context, network, addr string) (net.Conn, error) {
+	h.mu.RLock()
+	cache := h.tlsSessionCache
+	h.mu.RUnlock()
+	return h.dialTLSWith(ctx, network, addr, connOptions{ja3: h.connJA3Profile(), sessionCache: cache})
+}
+
+// dialTLSWith 使用 uTLS 按指定连接参数完成 TLS 握手。
+func (h *HttpClient) dialTLSWith(ctx context.Context, network, addr string, opts connOptions) (net.Conn, error) {
+	// 优先使用已配置的代理 Dialer（如 SOCKS5），避免绕过代理直连
+	rawConn, err := h.dialRaw(ctx, network, addr, opts)
+	if err != nil {
+		h.LogError("DialContext failed", err)
+		return nil, err
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		rawConn.Close()
+		h.LogError("SplitHostPort failed", err)
+		return nil, fmt.Errorf("invalid addr %s: %v", addr, err)
+	}
+	clientHelloID := getClientHelloID(opts.ja3)
+	config := h.utlsConfig(host)
+	config.ClientSessionCache = sessionCacheFor(opts.sessionCache, opts.ja3)
+	config.OmitEmptyPsk = true // 首次连接没有可用票据时省略 PSK 扩展，与浏览器行为一致
+	// 预设指纹会以其 supported_versions 覆盖 config 的版本范围，先记下配置值
+	minVersion, maxVersion := config.MinVersion, config.MaxVersion
+	uConn := utls.UClient(rawConn, config, clientHelloID)
+	// 提前构建 ClientHello，再直接修改 ALPNExtension，
+	// 确保只声明 http/1.1，阻止服务端协商 h2。
+	// 说明：Config.NextProtos 无法覆盖预设指纹的 ALPN，因为
+	// ALPNExtension.writeToUConn 会反向覆盖 config.NextProtos。
+	// 正确做法：BuildHandshakeState() 之后找到 ALPNExtension 并修改。
+	if err := uConn.BuildHandshakeState(); err != nil {
+		rawConn.Close()
+		h.LogError("BuildHandshakeState failed", err)
+		return nil, err
+	}
+	for _, ext := range uConn.Extensions {
+		switch e := ext.(type) {
+		case *utls.ALPNExtension:
+			e.AlpnProtocols = []string{"http/1.1"}
+		case *utls.SupportedVersionsExtension:
+			if e.Versions = filterTLSVersions(e.Versions, minVersion, maxVersion); !hasTLSVersion(e.Versions) {
+				rawConn.Close()
+				return nil, fmt.Errorf("tls: JA3 profile %q offers no version within the configured range", opts.ja3)
 			}
 		}
-		if err := uConn.Handshake(); err != nil {
-			rawConn.Close()
-			h.LogInfo("TLS handshake failed", "error", err)
-			return nil, err
-		}
-		return uConn, nil
 	}
-	h.client.Transport = h.transport
-	return nil
+	// 预设把 config 的版本范围设成了自身的范围，收窄回配置值，降级检测才按实际上限判断
+	if minVersion > config.MinVersion {
+		config.MinVersion = minVersion
+	}
+	if maxVersion != 0 && maxVersion < config.MaxVersion {
+		config.MaxVersion = maxVersion
+	}
+	hsCtx := ctx
+	if h.sockOpts.tlsHandshakeTimeout > 0 {
+		var cancel context.CancelFunc
+		hsCtx, cancel = context.WithTimeout(ctx, h.sockOpts.tlsHandshakeTimeout)
+		defer cancel()
+	}
+	// 自定义 TLS 拨号时 net/http 不会触发 TLS 握手钩子，在此手动回调
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.TLSHandshakeStart != nil {
+		trace.TLSHandshakeStart()
+	}
+	err = uConn.HandshakeContext(hsCtx)
+	if trace != nil && trace.TLSHandshakeDone != nil {
+		trace.TLSHandshakeDone(stdTLSState(uConn.ConnectionState()), err)
+	}
+	if err == nil {
+		err = checkTLSVersion(uConn.ConnectionState().Version, minVersion, maxVersion)
+	}
+	if err != nil {
+		rawConn.Close()
+		h.LogError("TLS handshake failed", err)
+		return nil, err
+	}
+	return newOrderedConn(uConn), nil
 }
 
 // DisableJA3 关闭 JA3 指纹模拟，恢复默认 TLS。
 func (h *HttpClient) DisableJA3() {
 	h.LogInfo("DisableJA3 called")
+	h.mu.Lock()
+	h.ja3Profile = ""
+	h.ja3Pool = nil
+	h.dialGen++
+	h.mu.Unlock()
 	if h.transport != nil {
 		h.transport.DialTLSContext = nil
-		h.client.Transport = h.transport
 	}
 	h.LogInfo("JA3 disabled, using default TLS")
 }
@@ -131,6 +263,8 @@ func getClientHelloID(profile string) utls.ClientHelloID {
 	switch profile {
 	case "chrome":
 		return utls.HelloChrome_120
+	case "chrome-psk":
+		return utls.HelloChrome_114_Padding_PSK_Shuf
 	case "firefox":
 		return utls.HelloFirefox_102
 	case "safari":
@@ -139,15 +273,16 @@ func getClientHelloID(profile string) utls.ClientHelloID {
 		return utls.HelloEdge_106
 	case "ios":
 		return utls.HelloIOS_14
+	case "random":
+		return utls.HelloRandomized
 	default:
 		return utls.HelloChrome_120
 	}
 }
 
-// SetTimeout 设置请求超时时间。
+// SetTimeout 设置请求整体超时时间；空闲连接超时与各阶段超时由 TransportConfig 单独配置。
 func (h *HttpClient) SetTimeout(timeout time.Duration) {
 	h.client.Timeout = timeout
-	h.transport.IdleConnTimeout = timeout
 	h.LogInfo("Timeout set", "duration", timeout)
 }
 
@@ -156,3 +291,11 @@ func (h *HttpClient) GetTimeout() time.Duration {
 	return h.client.Timeout
 }
 
+// SetRoundTripper 用 rt 替代底层网络传输（含 Session 的请求），用于注入测试用的 mock（见 reqtest 包），nil 恢复默认。
+// 代理、JA3、DNS 等传输层设置对 rt 不生效；cassette、重试、日志、CookieJar 等照常工作。
+func (h *HttpClient) SetRoundTripper(rt http.RoundTripper) {
+	h.mu.Lock()
+	h.rtBase = rt
+	h.mu.Unlock()
+	h.client.Transport = h.roundTripperFor(h.transport)
+}
@@ -15,11 +15,11 @@ func TestSetTimeout_AndGetTimeout(t *testing.T) {
 	}
 }
 
-func TestSetTimeout_UpdatesIdleConnTimeout(t *testing.T) {
-	c := NewHttpClient("http://example.com")
+func TestSetTimeout_KeepsIdleConnTimeout(t *testing.T) {
+	c := NewHttpClientWithTransport("http://example.com", &TransportConfig{IdleConnTimeout: 90 * time.Second})
 	c.SetTimeout(7 * time.Second)
-	if c.transport.IdleConnTimeout != 7*time.Second {
-		t.Fatalf("transport.IdleConnTimeout should be 7s, got %v", c.transport.IdleConnTimeout)
+	if c.transport.IdleConnTimeout != 90*time.Second {
+		t.Fatalf("SetTimeout should not touch IdleConnTimeout, got %v", c.transport.IdleConnTimeout)
 	}
 }
 
@@ -1,6 +1,15 @@
 package client
 
-import "time"
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	utls "github.com/refraction-networking/utls"
+)
 
 // ProxyConfig 代理配置。
 type ProxyConfig struct {
@@ -17,5 +26,118 @@ type TransportConfig struct {
 	MaxConnsPerHost     int
 	IdleConnTimeout     time.Duration
 	MaxConcurrency      int // 最大并发请求数，0 表示不限制
+	TLSSessionCacheSize int // JA3（uTLS）会话恢复缓存容量，0 使用默认值 1024，<0 关闭；票据按 profile 隔离，默认 "chrome" 仅 TLS 1.2 可恢复
+
+	// DNS：以下任一项非零即由本库解析主机名，作用于直连、SOCKS5 与 JA3 拨号
+	Resolve     map[string]string // 静态解析（类似 curl --resolve），host -> IP，多个 IP 以逗号分隔
+	DNSServer   string            // 自定义 DNS 服务器，如 "8.8.8.8:53"
+	Resolver    Resolver          // 自定义解析器，优先于 DNSServer
+	DNSCacheTTL time.Duration     // DNS 缓存 TTL 上限，>0 启用缓存；记录 TTL 更短时以记录为准，为 0 时不缓存
+	PreferIP    string            // IP 版本偏好：PreferIPv4 / PreferIPv6，空表示按解析顺序
+
+	// 分阶段超时，与整体请求超时（client.Timeout）相互独立；0 表示不单独限制
+	DialTimeout           time.Duration // TCP 建连超时，0 时为 30s，与请求超时无关
+	TLSHandshakeTimeout   time.Duration // TLS 握手超时，标准 TLS 与 JA3 均生效
+	ResponseHeaderTimeout time.Duration // 请求写完后等待响应头的超时
+	ExpectContinueTimeout time.Duration // 带 Expect: 100-continue 时等待服务端首个响应的超时
+
+	// Socket 选项
+	KeepAlive         time.Duration // TCP keep-alive 空闲多久后开始探测，0 使用系统默认 15s，<0 关闭
+	KeepAliveInterval time.Duration // keep-alive 探测间隔，0 使用默认 15s
+	KeepAliveCount    int           // keep-alive 最大探测次数，0 使用默认 9
+	DisableNoDelay    bool          // 关闭 TCP_NODELAY（Go 默认开启）
+	SocketMark        int           // SO_MARK，仅 Linux，需要 CAP_NET_ADMIN
+	TCPFastOpen       bool          // TCP Fast Open（TCP_FASTOPEN_CONNECT），仅 Linux
+
+	// Control 自定义 socket 选项钩子，在内置选项设置之后、connect 之前调用
+	Control func(network, address string, c syscall.RawConn) error
+
+	UnixSocket string // Unix domain socket 路径，非空时所有连接拨向该 socket（也可用 "unix://" domain）
+}
+
+// TLSConfig TLS 配置，同时作用于标准 TLS 与 JA3（uTLS）握手。
+type TLSConfig struct {
+	ClientCerts        []CertKeyPair     // 客户端证书（mTLS），PEM 文件
+	Certificates       []tls.Certificate // 已加载的客户端证书
+	RootCAFiles        []string          // 额外信任的 CA 证书文件（PEM），追加到系统根证书
+	RootCAPEM          []byte            // 额外信任的 CA 证书内容（PEM）
+	ServerName         string            // 覆盖 SNI 及证书校验使用的主机名
+	MinVersion         uint16            // 如 tls.VersionTLS12
+	MaxVersion         uint16
+	InsecureSkipVerify bool     // 跳过证书校验，仅用于测试环境
+	PinnedSPKI         []string // 固定公钥：base64(SHA-256(SubjectPublicKeyInfo))，见 SPKIHash
+}
+
+// CertKeyPair 客户端证书与私钥文件路径。
+type CertKeyPair struct {
+	CertFile string
+	KeyFile  string
+}
+
+// JA3Profile 带权重的 JA3 指纹 profile。
+type JA3Profile struct {
+	Name   string // chrome、firefox、safari、edge、ios、random
+	Weight int    // 抽取权重，<=0 按 1 处理
+}
+
+// JA3Config JA3 指纹轮换配置。
+type JA3Config struct {
+	Profiles []JA3Profile // 候选 profile；为空时固定使用 EnableJA3 传入的 profile
+	Rotate   string       // JA3RotateConnection 或 JA3RotateSession（默认）
+}
+
+// LocalAddrConfig 出口源地址配置。
+type LocalAddrConfig struct {
+	Addrs  []string // IP 或网卡名（如 "eth0"，取其全部非链路本地地址）
+	Rotate string   // LocalAddrRotateConnection（默认）或 LocalAddrRotateSession
+}
+
+// connOptions 单条连接的拨号与握手参数。
+type connOptions struct {
+	ja3          string
+	sessionCache utls.ClientSessionCache
+	localAddr    func(host string) net.IP // 选择源地址，nil 时按 client 级别轮换
+}
+
+// Request 描述一次通用请求，供 Do 使用。
+type Request struct {
+	Context context.Context // 可选，用于取消/超时
+	Method  string
+	Path    string      // 相对路径或完整 URL
+	Header  http.Header // 本次请求的请求头，同名时覆盖 client/Session 级别的 header
+	Body    []byte
+	Session *Session // 非 nil 时使用 Session 的 CookieJar 与请求头
+}
+
+// CurlCommand ParseCurl 的解析结果。
+type CurlCommand struct {
+	Request    *Request     // 可直接交给 Do 的请求，Path 为完整 URL
+	Proxy      *ProxyConfig // -x 指定的代理，nil 表示未指定
+	Insecure   bool         // -k / --insecure
+	Compressed bool         // --compressed（请求默认已带 Accept-Encoding: gzip 并自动解压）
+}
+
+// CassetteConfig cassette 录制/回放配置。方法与 URL（查询参数不区分顺序）总是参与匹配。
+type CassetteConfig struct {
+	Mode         CassetteMode // 默认 CassetteAuto
+	MatchBody    bool         // 请求体须完全一致
+	MatchHeaders []string     // 参与匹配的请求头；只有这些请求头（经脱敏）会写入 cassette
+	IgnoreQuery  []string     // 比较 URL 时忽略的查询参数，如时间戳、签名
+	// RawResponseBody 保存响应体原文；默认文本响应体与请求体一样经脱敏，回放时返回的是占位符
+	RawResponseBody bool
+	// Match 自定义匹配，非 nil 时替代以上规则（方法与 URL 仍须一致）；req/body 为原始请求，recorded 为脱敏后的记录
+	Match func(req *http.Request, body []byte, recorded *CassetteRequest) bool
+}
+
+// Response 通用请求的响应（body 已自动解压）。
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Conn       *ConnInfo // 实际使用的连接信息（TLS 版本、ALPN、对端地址、是否复用等）
+	Timing     *Timing   // 各阶段耗时（DNS、建连、TLS、首字节、传输等）
+	RequestID  string    // 本次请求的关联 ID
 }
 
+// dialFunc 与 http.Transport.DialContext 签名一致的拨号函数。
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Unix domain socket 传输。
+//
+// 两种启用方式：
+//   - 以 "unix:///var/run/docker.sock" 作为 domain（NewHttpClient / SetDomain），请求路径照常传入；
+//   - 在 TransportConfig.UnixSocket 中指定 socket 路径，domain 仍使用 http(s):// 地址，其主机名作为 Host 头。
+//
+// TransportConfig.UnixSocket 使该 client（含其 Session）的所有连接都拨向该 socket；unix:// domain 只接管
+// 相对路径的请求，绝对 URL 照常访问网络。走 socket 的连接上代理、DNS 与出口地址设置不再生效。
+const unixScheme = "unix://"
+
+// unixBaseURL unix:// domain 对应的请求 URL 前缀，Host 头为 localhost。
+const unixBaseURL = "http://localhost"
+
+// unixBaseAddr unixBaseURL 对应的拨号地址，拨向该地址的连接才会转到 unix:// domain 的 socket。
+const unixBaseAddr = "localhost:80"
+
+// unixSocketFor 返回拨向 addr 时应使用的 socket 路径，不走 socket 时为空。
+func (h *HttpClient) unixSocketFor(addr string) string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.unixSocket != "" {
+		return h.unixSocket
+	}
+	if addr == unixBaseAddr {
+		return domainSocket(h.domain)
+	}
+	return ""
+}
+
+// domainSocket 返回 unix:// domain 中的 socket 路径，其他 domain 返回空。
+func domainSocket(domain string) string {
+	if strings.HasPrefix(domain, unixScheme) {
+		return strings.TrimPrefix(domain, unixScheme)
+	}
+	return ""
+}
+
+// urlDialAddr 返回 URL 对应的拨号地址（host:port，省略端口时按协议补全）。
+func urlDialAddr(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	port := "80"
+	if u.Scheme == "https" {
+		port = "443"
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// dialUnix 忽略目标地址，连接到 Unix domain socket。
+func (h *HttpClient) dialUnix(ctx context.Context, path string) (net.Conn, error) {
+	return h.newDialer(nil).DialContext(ctx, "unix", path)
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// startUnixServer 在临时目录的 Unix socket 上启动 HTTP 服务端，返回 socket 路径。
+func startUnixServer(t *testing.T, h http.Handler) string {
+	dir, err := os.MkdirTemp("", "req")
+	if err != nil {
+		t.Fatalf("MkdirTemp failed: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "api.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("listen unix failed: %v", err)
+	}
+	ts := httptest.NewUnstartedServer(h)
+	ts.Listener = ln
+	ts.Start()
+	t.Cleanup(ts.Close)
+	return path
+}
+
+func TestUnixSocket_Domain(t *testing.T) {
+	path := startUnixServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method + " " + r.Host + r.URL.Path))
+	}))
+
+	c := NewHttpClient("unix://" + path)
+	body, err := c.DoGet("/v1/ping")
+	if err != nil {
+		t.Fatalf("DoGet failed: %v", err)
+	}
+	if string(body) != "GET localhost/v1/ping" {
+		t.Fatalf("unexpected body: %s", body)
+	}
+	resp, err := c.Do(&Request{Method: "POST", Path: "/v1/items", Body: []byte("x")})
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if string(resp.Body) != "POST localhost/v1/items" || resp.Conn.RemoteAddr != path {
+		t.Fatalf("unexpected response: %s via %s", resp.Body, resp.Conn.RemoteAddr)
+	}
+}
+
+func TestUnixSocket_TransportConfig(t *testing.T) {
+	path := startUnixServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "sid", Value: "1"})
+		if c, err := r.Cookie("sid"); err == nil {
+			w.Write([]byte("cookie " + c.Value + " @" + r.Host))
+			return
+		}
+		w.Write([]byte("new @" + r.Host))
+	}))
+
+	c := NewHttpClientWithTransport("http://docker", &TransportConfig{UnixSocket: path})
+	s := NewSession()
+	for _, want := range []string{"new @docker", "cookie 1 @docker"} {
+		body, err := c.DoGetWithSession(s, "/containers/json")
+		if err != nil {
+			t.Fatalf("DoGetWithSession failed: %v", err)
+		}
+		if string(body) != want {
+			t.Fatalf("expected %q, got %q", want, body)
+		}
+	}
+	if _, err := c.DoHead("/_ping"); err != nil {
+		t.Fatalf("DoHead failed: %v", err)
+	}
+}
+
+func TestUnixSocket_SwitchDomainAndAbsoluteURL(t *testing.T) {
+	serve := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name))
+		})
+	}
+	a, b := startUnixServer(t, serve("a")), startUnixServer(t, serve("b"))
+	ts := httptest.NewServer(serve("tcp"))
+	defer ts.Close()
+
+	c := NewHttpClient("unix://" + a)
+	for _, tc := range []struct{ domain, path, want string }{
+		{"unix://" + a, "/", "a"},
+		{"unix://" + b, "/", "b"}, // 切换 socket 后不复用旧 socket 的空闲连接
+		{"unix://" + b, ts.URL + "/", "tcp"},
+	} {
+		c.SetDomain(tc.domain)
+		body, err := c.DoGet(tc.path)
+		if err != nil || string(body) != tc.want {
+			t.Fatalf("%s %s: got %q, %v; want %q", tc.domain, tc.path, body, err, tc.want)
+		}
+	}
+}